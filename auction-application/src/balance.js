@@ -0,0 +1,96 @@
+'use strict';
+
+const path = require('path');
+const { Gateway } = require('fabric-network');
+
+const {
+  buildCCPOrg,
+  buildWallet,
+  checkArgs,
+  handleError,
+} = require('./utils/AppUtil');
+
+const myChannel = 'mychannel';
+const myChaincodeName = 'auction-chaincode';
+const myTokenContractName = 'token';
+
+/**
+ * @description Evaluates the account ID and the balance of the user.
+ * @param {*} ccp - The common connection profile.
+ * @param {Wallet} wallet - The wallet.
+ * @param {string} user - The user.
+ * @returns {Promise<void>}
+ */
+async function balance(ccp, wallet, user) {
+  try {
+    // Create a new gateway for connecting to our peer node.
+    const gateway = new Gateway();
+
+    // Connect using Discovery enabled.
+    await gateway.connect(ccp, {
+      wallet,
+      identity: user,
+      discovery: { enabled: true, asLocalhost: true },
+    });
+
+    // Get the token contract of the chaincode.
+    const network = await gateway.getNetwork(myChannel);
+    const contract = network.getContract(myChaincodeName, myTokenContractName);
+
+    console.log('\n--> Evaluate Transaction: Query the account ID');
+    let accountID = await contract.evaluateTransaction('ClientAccountID');
+    console.log(`\n*** Result: Account ID: ${accountID.toString()}`);
+
+    console.log('\n--> Evaluate Transaction: Query the account balance');
+    let result = await contract.evaluateTransaction('ClientAccountBalance');
+    console.log(`\n*** Result: Balance: ${result.toString()}`);
+
+    // Disconnect from the gateway.
+    await gateway.disconnect();
+  } catch (error) {
+    console.error(`Failed to evaluate balance transaction: ${error}`);
+  }
+}
+
+// Argument list for the script.
+const fileAndArgs = 'balance.js <org> <userID>';
+
+/**
+ * @description Print the account ID and the token balance of the user.
+ */
+async function main() {
+  try {
+    // Check if the user has provided all the required inputs.
+    checkArgs(
+      process.argv.length >= 4,
+      fileAndArgs,
+      'Missing required arguments: org, userID'
+    );
+
+    // Get all the arguments and validate them.
+    let [, , org, user] = process.argv;
+    checkArgs(
+      /^(org1|Org1|org2|Org2)$/.test(org),
+      fileAndArgs,
+      'Org must be either org1 or Org1 or org2 or Org2'
+    );
+    checkArgs(
+      /^[a-zA-Z0-9]+$/.test(user),
+      fileAndArgs,
+      'User ID must be a non-empty string'
+    );
+
+    org = org.toLowerCase();
+
+    const ccp = buildCCPOrg(org);
+    const walletPath = path.join(__dirname, `wallet/${org}`);
+    const wallet = await buildWallet(walletPath);
+
+    await balance(ccp, wallet, user);
+  } catch (error) {
+    handleError('Failed to run the balance query', error);
+  }
+}
+
+// Execute the main function.
+main();
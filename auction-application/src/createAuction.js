@@ -1,5 +1,6 @@
 'use strict';
 
+const crypto = require('crypto');
 const path = require('path');
 const { Gateway } = require('fabric-network');
 
@@ -11,6 +12,8 @@ const {
   prettyJSONString,
 } = require('./utils/AppUtil');
 
+const orgMSP1 = 'Org1MSP';
+const orgMSP2 = 'Org2MSP';
 const myChannel = 'mychannel';
 const myChaincodeName = 'auction-chaincode';
 
@@ -19,11 +22,34 @@ const myChaincodeName = 'auction-chaincode';
  * @param {*} ccp - The common connection profile.
  * @param {Wallet} wallet - The wallet.
  * @param {string} user - The user.
+ * @param {string} orgMSP - The org MSP.
  * @param {string} auctionID - The auction ID.
  * @param {string} item - The item.
+ * @param {string} format - The auction format.
+ * @param {number} biddingDeadline - The bidding deadline in seconds since the epoch.
+ * @param {number} revealDeadline - The reveal deadline in seconds since the epoch.
+ * @param {string} deposit - The deposit posted by every bid.
+ * @param {string} quantity - The number of units sold.
+ * @param {string} [reserve] - The optional reserve price, 0 for no reserve.
+ * @param {string[]} [invitees] - The MSP IDs and client IDs invited to bid, if
+ * the auction is invite-only.
  * @returns {Promise<void>}
  */
-async function createAuction(ccp, wallet, user, auctionID, item) {
+async function createAuction(
+  ccp,
+  wallet,
+  user,
+  orgMSP,
+  auctionID,
+  item,
+  format,
+  biddingDeadline,
+  revealDeadline,
+  deposit,
+  quantity,
+  reserve,
+  invitees
+) {
   try {
     // Create a new gateway for connecting to our peer node.
     const gateway = new Gateway();
@@ -39,11 +65,45 @@ async function createAuction(ccp, wallet, user, auctionID, item) {
     const network = await gateway.getNetwork(myChannel);
     const contract = network.getContract(myChaincodeName);
 
-    // Submit the transaction.
+    // Submit the transaction. The item is locked by the peers of your
+    // organization, which owns the item.
     let statefulTxt = contract.createTransaction('CreateAuction');
+    statefulTxt.setEndorsingOrganizations(orgMSP); // Set the endorsing orgs.
+
+    const transientData = {};
+    if (reserve !== undefined && parseInt(reserve) > 0) {
+      // Evaluate the submitting client identity.
+      let seller = await contract.evaluateTransaction(
+        'GetSubmittingClientIdentity'
+      );
+
+      // Reserve Data Structure.
+      let reserveData = {
+        objectType: 'reserve',
+        price: parseInt(reserve),
+        seller: seller.toString(),
+        salt: crypto.randomBytes(32).toString('hex'),
+      };
+
+      // The reserve is stored in the private data collection of your organization.
+      transientData.reserve = Buffer.from(JSON.stringify(reserveData)); // Convert the reserve data to a buffer.
+    }
+
+    if (Object.keys(transientData).length > 0) {
+      statefulTxt.setTransient(transientData); // Set the transient data.
+    }
 
     console.log('\n-> Submit Transaction: Propose a new auction');
-    await statefulTxt.submit(auctionID, item);
+    await statefulTxt.submit(
+      auctionID,
+      item,
+      format,
+      biddingDeadline.toString(),
+      revealDeadline.toString(),
+      deposit,
+      quantity,
+      JSON.stringify(invitees || []) // Only the invitees can bid, if any are given.
+    );
     console.log('\n*** Result: committed');
 
     // Evaluate the transaction.
@@ -61,7 +121,8 @@ async function createAuction(ccp, wallet, user, auctionID, item) {
 }
 
 // Argument list for the script.
-const fileAndArgs = 'createAuction.js <org> <userID> <auctionID> <item>';
+const fileAndArgs =
+  'createAuction.js <org> <userID> <auctionID> <item> <format> <biddingMinutes> <revealMinutes> <deposit> <quantity> [reserve] [invitee...]';
 
 /**
  * @description Creates an auction and submits it to the ledger.
@@ -74,13 +135,32 @@ async function main() {
         process.argv[2] === undefined ||
         process.argv[3] === undefined ||
         process.argv[4] === undefined ||
-        process.argv[5] === undefined,
+        process.argv[5] === undefined ||
+        process.argv[6] === undefined ||
+        process.argv[7] === undefined ||
+        process.argv[8] === undefined ||
+        process.argv[9] === undefined ||
+        process.argv[10] === undefined,
       fileAndArgs,
-      'Missing required arguments: org, userID, auctionID, item'
+      'Missing required arguments: org, userID, auctionID, item, format, biddingMinutes, revealMinutes, deposit, quantity'
     );
 
     // Get all the arguments.
-    let [, , org, user, auctionID, item] = process.argv;
+    let [
+      ,
+      ,
+      org,
+      user,
+      auctionID,
+      item,
+      format,
+      biddingMinutes,
+      revealMinutes,
+      deposit,
+      quantity,
+      reserve,
+    ] = process.argv;
+    const invitees = process.argv.slice(12);
     checkArgs(
       /^(org1|Org1|org2|Org2)$/.test(org),
       fileAndArgs,
@@ -101,14 +181,59 @@ async function main() {
       fileAndArgs,
       'Item must be a non-empty string'
     );
+    checkArgs(
+      /^(first-price|second-price)$/.test(format),
+      fileAndArgs,
+      'Format must be either first-price or second-price'
+    );
+    checkArgs(
+      /^[0-9]+$/.test(biddingMinutes) && /^[0-9]+$/.test(revealMinutes),
+      fileAndArgs,
+      'Bidding and reveal minutes must be numbers'
+    );
+    checkArgs(
+      /^[0-9]+$/.test(deposit),
+      fileAndArgs,
+      'Deposit must be a number'
+    );
+    checkArgs(
+      /^[1-9][0-9]*$/.test(quantity),
+      fileAndArgs,
+      'Quantity must be a positive number'
+    );
+    checkArgs(
+      reserve === undefined || /^[0-9]+$/.test(reserve),
+      fileAndArgs,
+      'Reserve must be a number, 0 for no reserve'
+    );
 
     org = org.toLowerCase();
 
+    // The deadlines are seconds since the epoch, the reveal period starts at
+    // the bidding deadline.
+    const biddingDeadline =
+      Math.floor(Date.now() / 1000) + parseInt(biddingMinutes) * 60;
+    const revealDeadline = biddingDeadline + parseInt(revealMinutes) * 60;
+
     const ccp = buildCCPOrg(org);
-    const walletPath = path.join(__dirname, `wallet/${org}-wallet`);
+    const walletPath = path.join(__dirname, `wallet/${org}`);
     const wallet = await buildWallet(walletPath);
 
-    await createAuction(ccp, wallet, user, auctionID, item);
+    await createAuction(
+      ccp,
+      wallet,
+      user,
+      org === 'org1' ? orgMSP1 : orgMSP2,
+      auctionID,
+      item,
+      format,
+      biddingDeadline,
+      revealDeadline,
+      deposit,
+      quantity,
+      reserve,
+      invitees
+    );
   } catch (error) {
     handleError('Failed to run the create auction', error);
   }
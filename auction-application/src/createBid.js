@@ -1,5 +1,6 @@
 'use strict';
 
+const crypto = require('crypto');
 const path = require('path');
 const { Gateway } = require('fabric-network');
 
@@ -23,10 +24,21 @@ const myChaincodeName = 'auction-chaincode';
  * @param {string} user - The user.
  * @param {string} orgMSP - The org MSP.
  * @param {string} auctionID - The auction ID.
- * @param {number} price - The price.
+ * @param {number} price - The unit price.
+ * @param {number} quantity - The number of units.
+ * @param {string} [side] - The side of an order of a double auction, bid or ask.
  * @returns {Promise<void>}
  */
-async function createBid(ccp, wallet, user, orgMSP, auctionID, price) {
+async function createBid(
+  ccp,
+  wallet,
+  user,
+  orgMSP,
+  auctionID,
+  price,
+  quantity,
+  side
+) {
   try {
     // Create a new gateway for connecting to our peer node.
     const gateway = new Gateway();
@@ -49,12 +61,16 @@ async function createBid(ccp, wallet, user, orgMSP, auctionID, price) {
     );
     console.log('*** Result: Bidder ID is ' + bidder.toString());
 
-    // Bid Data Structure.
+    // Bid Data Structure. The random salt keeps the price from being guessed
+    // from the bid hash that is published on the auction.
     let bidData = {
       objectType: 'bid',
       price: parseInt(price),
+      quantity: parseInt(quantity),
+      side: side,
       org: orgMSP,
       bidder: bidder.toString(),
+      salt: crypto.randomBytes(32).toString('hex'),
     };
 
     // Submit the transaction.
@@ -99,7 +115,8 @@ async function createBid(ccp, wallet, user, orgMSP, auctionID, price) {
 }
 
 // Argument list for the script.
-const fileAndArgs = 'createBid.js <org> <userID> <auctionID> <price>';
+const fileAndArgs =
+  'createBid.js <org> <userID> <auctionID> <price> [quantity] [side]';
 
 /**
  * @description Creates an bid and submits it to the ledger.
@@ -118,7 +135,8 @@ async function main() {
     );
 
     // Get all the arguments.
-    let [, , org, user, auctionID, price] = process.argv;
+    let [, , org, user, auctionID, price, quantity = '1', side] =
+      process.argv;
     checkArgs(
       /^(org1|Org1|org2|Org2)$/.test(org),
       fileAndArgs,
@@ -139,6 +157,16 @@ async function main() {
       fileAndArgs,
       'Price must be a non-empty string and must be a number'
     );
+    checkArgs(
+      /^[1-9][0-9]*$/.test(quantity),
+      fileAndArgs,
+      'Quantity must be a positive number'
+    );
+    checkArgs(
+      side === undefined || /^(bid|ask)$/.test(side),
+      fileAndArgs,
+      'Side must be either bid or ask'
+    );
 
     org = org.toLowerCase();
 
@@ -152,7 +180,9 @@ async function main() {
       user,
       org === 'org1' ? orgMSP1 : orgMSP2,
       auctionID,
-      price
+      price,
+      quantity,
+      side
     );
   } catch (error) {
     handleError('Failed to run the create auction', error);
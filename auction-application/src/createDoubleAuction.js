@@ -0,0 +1,175 @@
+'use strict';
+
+const path = require('path');
+const { Gateway } = require('fabric-network');
+
+const {
+  buildCCPOrg,
+  buildWallet,
+  checkArgs,
+  handleError,
+  prettyJSONString,
+} = require('./utils/AppUtil');
+
+const orgMSP1 = 'Org1MSP';
+const orgMSP2 = 'Org2MSP';
+const myChannel = 'mychannel';
+const myChaincodeName = 'auction-chaincode';
+
+/**
+ * @description Submits the create double auction transaction to the ledger and evaluates the result.
+ * @param {*} ccp - The common connection profile.
+ * @param {Wallet} wallet - The wallet.
+ * @param {string} user - The user.
+ * @param {string} orgMSP - The org MSP.
+ * @param {string} auctionID - The auction ID.
+ * @param {string} item - The item.
+ * @param {number} biddingDeadline - The bidding deadline in seconds since the epoch.
+ * @param {number} revealDeadline - The reveal deadline in seconds since the epoch.
+ * @param {string} deposit - The deposit posted by every order.
+ * @returns {Promise<void>}
+ */
+async function createDoubleAuction(
+  ccp,
+  wallet,
+  user,
+  orgMSP,
+  auctionID,
+  item,
+  biddingDeadline,
+  revealDeadline,
+  deposit
+) {
+  try {
+    // Create a new gateway for connecting to our peer node.
+    const gateway = new Gateway();
+
+    // Connect using Discovery enabled.
+    await gateway.connect(ccp, {
+      wallet,
+      identity: user,
+      discovery: { enabled: true, asLocalhost: true },
+    });
+
+    // Get the network (channel) our contract is deployed to.
+    const network = await gateway.getNetwork(myChannel);
+    const contract = network.getContract(myChaincodeName);
+
+    // Submit the transaction. Your identity runs the auction, which is endorsed
+    // by the peers of your organization.
+    let statefulTxt = contract.createTransaction('CreateDoubleAuction');
+    statefulTxt.setEndorsingOrganizations(orgMSP); // Set the endorsing orgs.
+
+    console.log('\n-> Submit Transaction: Propose a new double auction');
+    await statefulTxt.submit(
+      auctionID,
+      item,
+      biddingDeadline.toString(),
+      revealDeadline.toString(),
+      deposit
+    );
+    console.log('\n*** Result: committed');
+
+    // Evaluate the transaction.
+    console.log(
+      '\n--> Evaluate Transaction: Query the auction that was just created'
+    );
+    let result = await contract.evaluateTransaction('QueryAuction', auctionID);
+    console.log('\n*** Result: Auction: ', prettyJSONString(result.toString()));
+
+    // Disconnect from the gateway.
+    await gateway.disconnect();
+  } catch (error) {
+    console.error(`Failed to submit create double auction transaction: ${error}`);
+  }
+}
+
+// Argument list for the script.
+const fileAndArgs =
+  'createDoubleAuction.js <org> <userID> <auctionID> <item> <biddingMinutes> <revealMinutes> <deposit>';
+
+/**
+ * @description Creates a double auction where buyers and sellers trade units with sealed orders.
+ */
+async function main() {
+  try {
+    // Check if the user has provided all the required inputs.
+    checkArgs(
+      process.argv.length >= 9,
+      fileAndArgs,
+      'Missing required arguments: org, userID, auctionID, item, biddingMinutes, revealMinutes, deposit'
+    );
+
+    // Get all the arguments and validate them.
+    let [
+      ,
+      ,
+      org,
+      user,
+      auctionID,
+      item,
+      biddingMinutes,
+      revealMinutes,
+      deposit,
+    ] = process.argv;
+    checkArgs(
+      /^(org1|Org1|org2|Org2)$/.test(org),
+      fileAndArgs,
+      'Org must be either org1 or Org1 or org2 or Org2'
+    );
+    checkArgs(
+      /^[a-zA-Z0-9]+$/.test(user),
+      fileAndArgs,
+      'User ID must be a non-empty string'
+    );
+    checkArgs(
+      /^[0-9]+$/.test(auctionID),
+      fileAndArgs,
+      'Auction ID must be a non-empty string and must be a number'
+    );
+    checkArgs(
+      /^[a-zA-Z0-9]+$/.test(item),
+      fileAndArgs,
+      'Item must be a non-empty string'
+    );
+    checkArgs(
+      /^[0-9]+$/.test(biddingMinutes) && /^[0-9]+$/.test(revealMinutes),
+      fileAndArgs,
+      'Bidding and reveal minutes must be numbers'
+    );
+    checkArgs(
+      /^[1-9][0-9]*$/.test(deposit),
+      fileAndArgs,
+      'Deposit must be a positive number'
+    );
+
+    org = org.toLowerCase();
+
+    // The deadlines are seconds since the epoch, the reveal period starts at
+    // the bidding deadline.
+    const biddingDeadline =
+      Math.floor(Date.now() / 1000) + parseInt(biddingMinutes) * 60;
+    const revealDeadline = biddingDeadline + parseInt(revealMinutes) * 60;
+
+    const ccp = buildCCPOrg(org);
+    const walletPath = path.join(__dirname, `wallet/${org}`);
+    const wallet = await buildWallet(walletPath);
+
+    await createDoubleAuction(
+      ccp,
+      wallet,
+      user,
+      org === 'org1' ? orgMSP1 : orgMSP2,
+      auctionID,
+      item,
+      biddingDeadline,
+      revealDeadline,
+      deposit
+    );
+  } catch (error) {
+    handleError('Failed to run the create double auction', error);
+  }
+}
+
+// Execute the main function.
+main();
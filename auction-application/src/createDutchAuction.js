@@ -0,0 +1,185 @@
+'use strict';
+
+const path = require('path');
+const { Gateway } = require('fabric-network');
+
+const {
+  buildCCPOrg,
+  buildWallet,
+  checkArgs,
+  handleError,
+  prettyJSONString,
+} = require('./utils/AppUtil');
+
+const orgMSP1 = 'Org1MSP';
+const orgMSP2 = 'Org2MSP';
+const myChannel = 'mychannel';
+const myChaincodeName = 'auction-chaincode';
+
+/**
+ * @description Submits the create dutch auction transaction to the ledger and evaluates the result.
+ * @param {*} ccp - The common connection profile.
+ * @param {Wallet} wallet - The wallet.
+ * @param {string} user - The user.
+ * @param {string} orgMSP - The org MSP.
+ * @param {string} auctionID - The auction ID.
+ * @param {string} item - The item.
+ * @param {string} startPrice - The start price.
+ * @param {string} floorPrice - The floor price.
+ * @param {string} decrement - The price decrement.
+ * @param {number} interval - The decrement interval in seconds.
+ * @param {number} deadline - The deadline in seconds since the epoch.
+ * @returns {Promise<void>}
+ */
+async function createDutchAuction(
+  ccp,
+  wallet,
+  user,
+  orgMSP,
+  auctionID,
+  item,
+  startPrice,
+  floorPrice,
+  decrement,
+  interval,
+  deadline
+) {
+  try {
+    // Create a new gateway for connecting to our peer node.
+    const gateway = new Gateway();
+
+    // Connect using Discovery enabled.
+    await gateway.connect(ccp, {
+      wallet,
+      identity: user,
+      discovery: { enabled: true, asLocalhost: true },
+    });
+
+    // Get the network (channel) our contract is deployed to.
+    const network = await gateway.getNetwork(myChannel);
+    const contract = network.getContract(myChaincodeName);
+
+    // Submit the transaction. The item is locked by the peers of your
+    // organization, which owns the item.
+    let statefulTxt = contract.createTransaction('CreateDutchAuction');
+    statefulTxt.setEndorsingOrganizations(orgMSP); // Set the endorsing orgs.
+
+    console.log('\n-> Submit Transaction: Propose a new dutch auction');
+    await statefulTxt.submit(
+      auctionID,
+      item,
+      startPrice,
+      floorPrice,
+      decrement,
+      interval.toString(),
+      deadline.toString()
+    );
+    console.log('\n*** Result: committed');
+
+    // Evaluate the transaction.
+    console.log(
+      '\n--> Evaluate Transaction: Query the auction that was just created'
+    );
+    let result = await contract.evaluateTransaction('QueryAuction', auctionID);
+    console.log('\n*** Result: Auction: ', prettyJSONString(result.toString()));
+
+    // Disconnect from the gateway.
+    await gateway.disconnect();
+  } catch (error) {
+    console.error(`Failed to submit create dutch auction transaction: ${error}`);
+  }
+}
+
+// Argument list for the script.
+const fileAndArgs =
+  'createDutchAuction.js <org> <userID> <auctionID> <item> <startPrice> <floorPrice> <decrement> <intervalMinutes> <minutes>';
+
+/**
+ * @description Creates a dutch auction whose price drops every interval until a buyer accepts it.
+ */
+async function main() {
+  try {
+    // Check if the user has provided all the required inputs.
+    checkArgs(
+      process.argv.length >= 11,
+      fileAndArgs,
+      'Missing required arguments: org, userID, auctionID, item, startPrice, floorPrice, decrement, intervalMinutes, minutes'
+    );
+
+    // Get all the arguments and validate them.
+    let [
+      ,
+      ,
+      org,
+      user,
+      auctionID,
+      item,
+      startPrice,
+      floorPrice,
+      decrement,
+      intervalMinutes,
+      minutes,
+    ] = process.argv;
+    checkArgs(
+      /^(org1|Org1|org2|Org2)$/.test(org),
+      fileAndArgs,
+      'Org must be either org1 or Org1 or org2 or Org2'
+    );
+    checkArgs(
+      /^[a-zA-Z0-9]+$/.test(user),
+      fileAndArgs,
+      'User ID must be a non-empty string'
+    );
+    checkArgs(
+      /^[0-9]+$/.test(auctionID),
+      fileAndArgs,
+      'Auction ID must be a non-empty string and must be a number'
+    );
+    checkArgs(
+      /^[a-zA-Z0-9]+$/.test(item),
+      fileAndArgs,
+      'Item must be a non-empty string'
+    );
+    checkArgs(
+      /^[0-9]+$/.test(startPrice) &&
+        /^[0-9]+$/.test(floorPrice) &&
+        /^[0-9]+$/.test(decrement),
+      fileAndArgs,
+      'Start price, floor price and decrement must be numbers'
+    );
+    checkArgs(
+      /^[0-9]+$/.test(intervalMinutes) && /^[0-9]+$/.test(minutes),
+      fileAndArgs,
+      'Interval minutes and minutes must be numbers'
+    );
+
+    org = org.toLowerCase();
+
+    // The interval is in seconds, and the deadline in seconds since the epoch.
+    const interval = parseInt(intervalMinutes) * 60;
+    const deadline = Math.floor(Date.now() / 1000) + parseInt(minutes) * 60;
+
+    const ccp = buildCCPOrg(org);
+    const walletPath = path.join(__dirname, `wallet/${org}`);
+    const wallet = await buildWallet(walletPath);
+
+    await createDutchAuction(
+      ccp,
+      wallet,
+      user,
+      org === 'org1' ? orgMSP1 : orgMSP2,
+      auctionID,
+      item,
+      startPrice,
+      floorPrice,
+      decrement,
+      interval,
+      deadline
+    );
+  } catch (error) {
+    handleError('Failed to run the create dutch auction', error);
+  }
+}
+
+// Execute the main function.
+main();
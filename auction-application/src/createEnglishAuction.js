@@ -0,0 +1,168 @@
+'use strict';
+
+const path = require('path');
+const { Gateway } = require('fabric-network');
+
+const {
+  buildCCPOrg,
+  buildWallet,
+  checkArgs,
+  handleError,
+  prettyJSONString,
+} = require('./utils/AppUtil');
+
+const orgMSP1 = 'Org1MSP';
+const orgMSP2 = 'Org2MSP';
+const myChannel = 'mychannel';
+const myChaincodeName = 'auction-chaincode';
+
+/**
+ * @description Submits the create english auction transaction to the ledger and evaluates the result.
+ * @param {*} ccp - The common connection profile.
+ * @param {Wallet} wallet - The wallet.
+ * @param {string} user - The user.
+ * @param {string} orgMSP - The org MSP.
+ * @param {string} auctionID - The auction ID.
+ * @param {string} item - The item.
+ * @param {string} startPrice - The minimum price of the first bid.
+ * @param {string} minIncrement - The minimum increment of the bids.
+ * @param {number} deadline - The deadline in seconds since the epoch.
+ * @returns {Promise<void>}
+ */
+async function createEnglishAuction(
+  ccp,
+  wallet,
+  user,
+  orgMSP,
+  auctionID,
+  item,
+  startPrice,
+  minIncrement,
+  deadline
+) {
+  try {
+    // Create a new gateway for connecting to our peer node.
+    const gateway = new Gateway();
+
+    // Connect using Discovery enabled.
+    await gateway.connect(ccp, {
+      wallet,
+      identity: user,
+      discovery: { enabled: true, asLocalhost: true },
+    });
+
+    // Get the network (channel) our contract is deployed to.
+    const network = await gateway.getNetwork(myChannel);
+    const contract = network.getContract(myChaincodeName);
+
+    // Submit the transaction. The item is locked by the peers of your
+    // organization, which owns the item.
+    let statefulTxt = contract.createTransaction('CreateEnglishAuction');
+    statefulTxt.setEndorsingOrganizations(orgMSP); // Set the endorsing orgs.
+
+    console.log('\n-> Submit Transaction: Propose a new english auction');
+    await statefulTxt.submit(
+      auctionID,
+      item,
+      startPrice,
+      minIncrement,
+      deadline.toString()
+    );
+    console.log('\n*** Result: committed');
+
+    // Evaluate the transaction.
+    console.log(
+      '\n--> Evaluate Transaction: Query the auction that was just created'
+    );
+    let result = await contract.evaluateTransaction('QueryAuction', auctionID);
+    console.log('\n*** Result: Auction: ', prettyJSONString(result.toString()));
+
+    // Disconnect from the gateway.
+    await gateway.disconnect();
+  } catch (error) {
+    console.error(`Failed to submit create english auction transaction: ${error}`);
+  }
+}
+
+// Argument list for the script.
+const fileAndArgs =
+  'createEnglishAuction.js <org> <userID> <auctionID> <item> <startPrice> <minIncrement> <minutes>';
+
+/**
+ * @description Creates an english auction with public bids that have to beat the high bid.
+ */
+async function main() {
+  try {
+    // Check if the user has provided all the required inputs.
+    checkArgs(
+      process.argv.length >= 9,
+      fileAndArgs,
+      'Missing required arguments: org, userID, auctionID, item, startPrice, minIncrement, minutes'
+    );
+
+    // Get all the arguments and validate them.
+    let [
+      ,
+      ,
+      org,
+      user,
+      auctionID,
+      item,
+      startPrice,
+      minIncrement,
+      minutes,
+    ] = process.argv;
+    checkArgs(
+      /^(org1|Org1|org2|Org2)$/.test(org),
+      fileAndArgs,
+      'Org must be either org1 or Org1 or org2 or Org2'
+    );
+    checkArgs(
+      /^[a-zA-Z0-9]+$/.test(user),
+      fileAndArgs,
+      'User ID must be a non-empty string'
+    );
+    checkArgs(
+      /^[0-9]+$/.test(auctionID),
+      fileAndArgs,
+      'Auction ID must be a non-empty string and must be a number'
+    );
+    checkArgs(
+      /^[a-zA-Z0-9]+$/.test(item),
+      fileAndArgs,
+      'Item must be a non-empty string'
+    );
+    checkArgs(
+      /^[0-9]+$/.test(startPrice) && /^[0-9]+$/.test(minIncrement),
+      fileAndArgs,
+      'Start price and minimum increment must be numbers'
+    );
+    checkArgs(/^[0-9]+$/.test(minutes), fileAndArgs, 'Minutes must be a number');
+
+    org = org.toLowerCase();
+
+    // The deadline is in seconds since the epoch.
+    const deadline = Math.floor(Date.now() / 1000) + parseInt(minutes) * 60;
+
+    const ccp = buildCCPOrg(org);
+    const walletPath = path.join(__dirname, `wallet/${org}`);
+    const wallet = await buildWallet(walletPath);
+
+    await createEnglishAuction(
+      ccp,
+      wallet,
+      user,
+      org === 'org1' ? orgMSP1 : orgMSP2,
+      auctionID,
+      item,
+      startPrice,
+      minIncrement,
+      deadline
+    );
+  } catch (error) {
+    handleError('Failed to run the create english auction', error);
+  }
+}
+
+// Execute the main function.
+main();
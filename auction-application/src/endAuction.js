@@ -46,6 +46,30 @@ async function endAuction(ccp, wallet, user, auctionID) {
     // Submit the transaction.
     let statefulTxt = contract.createTransaction('EndAuction');
 
+    // Reveal the reserve price if the auction has one. Only the seller and the
+    // auctioneers of the seller's organization can read it, the other users end
+    // the auction without it once its reveal period has passed.
+    if (auction.reserveHash) {
+      let clientID = await contract.evaluateTransaction(
+        'GetSubmittingClientIdentity'
+      );
+
+      console.log('\n--> Evaluate Transaction: Query Stored Reserve');
+      try {
+        // Pass the reserve as it is stored, so that its hash matches.
+        let reserve = await contract.evaluateTransaction(
+          'QueryStoredReserve',
+          auctionID
+        );
+        statefulTxt.setTransient({ reserve: reserve }); // Set the transient data.
+      } catch (error) {
+        if (clientID.toString() === auction.seller) {
+          throw error;
+        }
+        console.log('\n*** The reserve is not revealed, the user does not act for the seller');
+      }
+    }
+
     // Set the endorsing orgs.
     if (auction.organizations.length === 2) {
       statefulTxt.setEndorsingOrganizations(
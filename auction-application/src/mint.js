@@ -0,0 +1,103 @@
+'use strict';
+
+const path = require('path');
+const { Gateway } = require('fabric-network');
+
+const {
+  buildCCPOrg,
+  buildWallet,
+  checkArgs,
+  handleError,
+} = require('./utils/AppUtil');
+
+const myChannel = 'mychannel';
+const myChaincodeName = 'auction-chaincode';
+const myTokenContractName = 'token';
+
+/**
+ * @description Submits the mint transaction to the ledger and evaluates the balance.
+ * @param {*} ccp - The common connection profile.
+ * @param {Wallet} wallet - The wallet.
+ * @param {string} user - The user.
+ * @param {string} amount - The amount of tokens to mint.
+ * @returns {Promise<void>}
+ */
+async function mint(ccp, wallet, user, amount) {
+  try {
+    // Create a new gateway for connecting to our peer node.
+    const gateway = new Gateway();
+
+    // Connect using Discovery enabled.
+    await gateway.connect(ccp, {
+      wallet,
+      identity: user,
+      discovery: { enabled: true, asLocalhost: true },
+    });
+
+    // Get the token contract of the chaincode.
+    const network = await gateway.getNetwork(myChannel);
+    const contract = network.getContract(myChaincodeName, myTokenContractName);
+
+    console.log('\n-> Submit Transaction: Mint tokens');
+    await contract.submitTransaction('Mint', amount);
+    console.log('\n*** Result: committed');
+
+    // Evaluate the transaction.
+    console.log('\n--> Evaluate Transaction: Query the account balance');
+    let result = await contract.evaluateTransaction('ClientAccountBalance');
+    console.log(`\n*** Result: Balance: ${result.toString()}`);
+
+    // Disconnect from the gateway.
+    await gateway.disconnect();
+  } catch (error) {
+    console.error(`Failed to submit mint transaction: ${error}`);
+  }
+}
+
+// Argument list for the script.
+const fileAndArgs = 'mint.js <org> <userID> <amount>';
+
+/**
+ * @description Mint tokens in the account of the user, who must be a member of Org1.
+ */
+async function main() {
+  try {
+    // Check if the user has provided all the required inputs.
+    checkArgs(
+      process.argv.length >= 5,
+      fileAndArgs,
+      'Missing required arguments: org, userID, amount'
+    );
+
+    // Get all the arguments and validate them.
+    let [, , org, user, amount] = process.argv;
+    checkArgs(
+      /^(org1|Org1|org2|Org2)$/.test(org),
+      fileAndArgs,
+      'Org must be either org1 or Org1 or org2 or Org2'
+    );
+    checkArgs(
+      /^[a-zA-Z0-9]+$/.test(user),
+      fileAndArgs,
+      'User ID must be a non-empty string'
+    );
+    checkArgs(
+      /^[0-9]+$/.test(amount),
+      fileAndArgs,
+      'Amount must be a non-empty string and must be a number'
+    );
+
+    org = org.toLowerCase();
+
+    const ccp = buildCCPOrg(org);
+    const walletPath = path.join(__dirname, `wallet/${org}`);
+    const wallet = await buildWallet(walletPath);
+
+    await mint(ccp, wallet, user, amount);
+  } catch (error) {
+    handleError('Failed to run the mint', error);
+  }
+}
+
+// Execute the main function.
+main();
@@ -0,0 +1,116 @@
+'use strict';
+
+const path = require('path');
+const { Gateway } = require('fabric-network');
+
+const {
+  buildCCPOrg,
+  buildWallet,
+  checkArgs,
+  handleError,
+  prettyJSONString,
+} = require('./utils/AppUtil');
+
+const myChannel = 'mychannel';
+const myChaincodeName = 'auction-chaincode';
+
+/**
+ * @description Submits the place open bid transaction to the ledger and evaluates the result.
+ * @param {*} ccp - The common connection profile.
+ * @param {Wallet} wallet - The wallet.
+ * @param {string} user - The user.
+ * @param {string} auctionID - The auction ID.
+ * @param {string} price - The price of the bid.
+ * @returns {Promise<void>}
+ */
+async function placeOpenBid(ccp, wallet, user, auctionID, price) {
+  try {
+    // Create a new gateway for connecting to our peer node.
+    const gateway = new Gateway();
+
+    // Connect using Discovery enabled.
+    await gateway.connect(ccp, {
+      wallet,
+      identity: user,
+      discovery: { enabled: true, asLocalhost: true },
+    });
+
+    // Get the network (channel) our contract is deployed to.
+    const network = await gateway.getNetwork(myChannel);
+    const contract = network.getContract(myChaincodeName);
+
+    // Query the auction to get the high bid and the endorsing orgs.
+    console.log('\n--> Evaluate Transaction: Query Auction');
+    let auction = await contract.evaluateTransaction('QueryAuction', auctionID);
+    auction = JSON.parse(auction); // Convert the JSON string to an object.
+    console.log(`\n*** Result: High bid: ${auction.price}`);
+
+    // Submit the transaction, endorsed by the organizations of the auction.
+    let statefulTxt = contract.createTransaction('PlaceOpenBid');
+    statefulTxt.setEndorsingOrganizations(...auction.organizations);
+
+    console.log('\n-> Submit Transaction: Place an open bid on the auction');
+    await statefulTxt.submit(auctionID, price);
+    console.log('\n*** Result: committed');
+
+    // Evaluate the transaction.
+    console.log('\n--> Evaluate Transaction: Query the updated auction');
+    let result = await contract.evaluateTransaction('QueryAuction', auctionID);
+    console.log('\n*** Result: Auction: ', prettyJSONString(result.toString()));
+
+    // Disconnect from the gateway.
+    await gateway.disconnect();
+  } catch (error) {
+    console.error(`Failed to submit place open bid transaction: ${error}`);
+    process.exit(1);
+  }
+}
+
+// Argument list for the script.
+const fileAndArgs = 'placeOpenBid.js <org> <userID> <auctionID> <price>';
+
+/**
+ * @description Places a public bid on an english auction.
+ */
+async function main() {
+  try {
+    // Check if the user has provided all the required inputs.
+    checkArgs(
+      process.argv.length >= 6,
+      fileAndArgs,
+      'Missing required arguments: org, userID, auctionID, price'
+    );
+
+    // Get all the arguments and validate them.
+    let [, , org, user, auctionID, price] = process.argv;
+    checkArgs(
+      /^(org1|Org1|org2|Org2)$/.test(org),
+      fileAndArgs,
+      'Org must be either org1 or Org1 or org2 or Org2'
+    );
+    checkArgs(
+      /^[a-zA-Z0-9]+$/.test(user),
+      fileAndArgs,
+      'User ID must be a non-empty string'
+    );
+    checkArgs(
+      /^[0-9]+$/.test(auctionID),
+      fileAndArgs,
+      'Auction ID must be a non-empty string and must be a number'
+    );
+    checkArgs(/^[0-9]+$/.test(price), fileAndArgs, 'Price must be a number');
+
+    org = org.toLowerCase();
+
+    const ccp = buildCCPOrg(org);
+    const walletPath = path.join(__dirname, `wallet/${org}`);
+    const wallet = await buildWallet(walletPath);
+
+    await placeOpenBid(ccp, wallet, user, auctionID, price);
+  } catch (error) {
+    handleError('Failed to run the place open bid', error);
+  }
+}
+
+// Execute the main function.
+main();
@@ -18,9 +18,10 @@ const mspOrg2 = 'Org2MSP';
  * @param {string} userId - The ID of the user.
  * @param {string} orgName - The name of the organization e.g. org1 or org2.
  * @param {string} mspOrg - The MSP ID of the Org.
+ * @param {string} [roles] - The auction roles of the user, separated by commas.
  * @returns {Promise<void>}
  */
-async function connectToOrgCA(userId, orgName, mspOrg) {
+async function connectToOrgCA(userId, orgName, mspOrg, roles) {
   console.log(`\n--> Register and enrolling a new user: ${userId}`);
   const ccpOrg = buildCCPOrg(orgName);
   const caOrgClient = buildCAClient(ccpOrg, `ca.${orgName}.example.com`);
@@ -33,12 +34,13 @@ async function connectToOrgCA(userId, orgName, mspOrg) {
     walletOrg,
     mspOrg,
     userId,
-    `${orgName}.department1`
+    `${orgName}.department1`,
+    roles
   );
 }
 
 // Argument list for the script.
-const fileAndArgs = 'registerAndEnrollUser.js <org> <userID>';
+const fileAndArgs = 'registerAndEnrollUser.js <org> <userID> [roles]';
 
 /**
  * Register and enrolls the user of the Org1 CA or Org2 CA.
@@ -46,17 +48,12 @@ const fileAndArgs = 'registerAndEnrollUser.js <org> <userID>';
 async function main() {
   try {
     checkArgs(
-      process.argv[2] === undefined || process.argv[3] === undefined,
+      process.argv.length >= 4,
       fileAndArgs,
       'Missing required argument: org, userID'
     );
 
-    if (process.argv[2] === undefined && process.argv[3] === undefined) {
-      console.log('Usage: node registerAndEnrollUser.js Org userID');
-      process.exit(1);
-    }
-
-    let [, , org, userId] = process.argv;
+    let [, , org, userId, roles] = process.argv;
     checkArgs(
       /^(org1|Org1|org2|Org2)$/.test(org),
       fileAndArgs,
@@ -65,7 +62,21 @@ async function main() {
 
     org = org.toLowerCase();
 
-    await connectToOrgCA(userId, org, org === 'org1' ? mspOrg1 : mspOrg2);
+    checkArgs(
+      roles === undefined ||
+        roles
+          .split(',')
+          .every((role) => /^(seller|bidder|auctioneer)$/.test(role.trim())),
+      fileAndArgs,
+      'Roles must be seller, bidder or auctioneer, separated by commas'
+    );
+
+    await connectToOrgCA(
+      userId,
+      org,
+      org === 'org1' ? mspOrg1 : mspOrg2,
+      roles
+    );
   } catch (error) {
     handleError('Failed to run the register and enroll user', error);
   }
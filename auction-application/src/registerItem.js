@@ -0,0 +1,118 @@
+'use strict';
+
+const path = require('path');
+const { Gateway } = require('fabric-network');
+
+const {
+  buildCCPOrg,
+  buildWallet,
+  checkArgs,
+  handleError,
+  prettyJSONString,
+} = require('./utils/AppUtil');
+
+const orgMSP1 = 'Org1MSP';
+const orgMSP2 = 'Org2MSP';
+const myChannel = 'mychannel';
+const myChaincodeName = 'auction-chaincode';
+
+/**
+ * @description Submits the register item transaction to the ledger and evaluates the result.
+ * @param {*} ccp - The common connection profile.
+ * @param {Wallet} wallet - The wallet.
+ * @param {string} user - The user.
+ * @param {string} orgMSP - The org MSP.
+ * @param {string} itemID - The item ID.
+ * @param {string} description - The description of the item.
+ * @returns {Promise<void>}
+ */
+async function registerItem(ccp, wallet, user, orgMSP, itemID, description) {
+  try {
+    // Create a new gateway for connecting to our peer node.
+    const gateway = new Gateway();
+
+    // Connect using Discovery enabled.
+    await gateway.connect(ccp, {
+      wallet,
+      identity: user,
+      discovery: { enabled: true, asLocalhost: true },
+    });
+
+    // Get the network (channel) our contract is deployed to.
+    const network = await gateway.getNetwork(myChannel);
+    const contract = network.getContract(myChaincodeName);
+
+    // Submit the transaction. The item is endorsed by your organization.
+    let statefulTxt = contract.createTransaction('RegisterItem');
+    statefulTxt.setEndorsingOrganizations(orgMSP);
+
+    console.log('\n-> Submit Transaction: Register a new item');
+    await statefulTxt.submit(itemID, description);
+    console.log('\n*** Result: committed');
+
+    // Evaluate the transaction.
+    console.log('\n--> Evaluate Transaction: Read the item that was registered');
+    let result = await contract.evaluateTransaction('ReadItem', itemID);
+    console.log('\n*** Result: Item: ', prettyJSONString(result.toString()));
+
+    // Disconnect from the gateway.
+    await gateway.disconnect();
+  } catch (error) {
+    console.error(`Failed to submit register item transaction: ${error}`);
+  }
+}
+
+// Argument list for the script.
+const fileAndArgs = 'registerItem.js <org> <userID> <itemID> <description>';
+
+/**
+ * @description Registers an item owned by the user, which can then be sold by an auction.
+ */
+async function main() {
+  try {
+    // Check if the user has provided all the required inputs.
+    checkArgs(
+      process.argv.length >= 6,
+      fileAndArgs,
+      'Missing required arguments: org, userID, itemID, description'
+    );
+
+    // Get all the arguments and validate them.
+    let [, , org, user, itemID, description] = process.argv;
+    checkArgs(
+      /^(org1|Org1|org2|Org2)$/.test(org),
+      fileAndArgs,
+      'Org must be either org1 or Org1 or org2 or Org2'
+    );
+    checkArgs(
+      /^[a-zA-Z0-9]+$/.test(user),
+      fileAndArgs,
+      'User ID must be a non-empty string'
+    );
+    checkArgs(
+      /^[a-zA-Z0-9]+$/.test(itemID),
+      fileAndArgs,
+      'Item ID must be a non-empty string'
+    );
+
+    org = org.toLowerCase();
+
+    const ccp = buildCCPOrg(org);
+    const walletPath = path.join(__dirname, `wallet/${org}`);
+    const wallet = await buildWallet(walletPath);
+
+    await registerItem(
+      ccp,
+      wallet,
+      user,
+      org === 'org1' ? orgMSP1 : orgMSP2,
+      itemID,
+      description
+    );
+  } catch (error) {
+    handleError('Failed to run the register item', error);
+  }
+}
+
+// Execute the main function.
+main();
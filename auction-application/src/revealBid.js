@@ -39,30 +39,26 @@ async function addBid(ccp, wallet, user, auctionID, bidID) {
     const network = await gateway.getNetwork(myChannel);
     const contract = network.getContract(myChaincodeName);
 
-    // Query the bid. (This is a read-only transaction.)
-    console.log('\n--> Evaluate Transaction: Query Bid');
-    let bid = await contract.evaluateTransaction('QueryBid', auctionID, bidID);
-    bid = JSON.parse(bid); // Convert the JSON string to an object.
+    // Query the bid as it is stored, so that it matches the hash of the bid.
+    // (This is a read-only transaction.)
+    console.log('\n--> Evaluate Transaction: Query Stored Bid');
+    const bid = await contract.evaluateTransaction(
+      'QueryStoredBid',
+      auctionID,
+      bidID
+    );
 
     // Query the auction. (This is a read-only transaction.)
     console.log('\n--> Evaluate Transaction: Query Auction');
     let auction = await contract.evaluateTransaction('QueryAuction', auctionID);
     auction = JSON.parse(auction); // Convert the JSON string to an object.
 
-    // Bid Data Structure.
-    let bidData = {
-      objectType: 'bid',
-      price: parseInt(bid.price),
-      org: bid.org,
-      bidder: bid.bidder,
-    };
-    console.log('*** Result: Bid: ', JSON.stringify(bidData, null, 2));
+    console.log('*** Result: Bid: ', prettyJSONString(bid.toString()));
 
     // Submit the transaction.
     let statefulTxt = contract.createTransaction('RevealBid');
 
-    let transientMapData = Buffer.from(JSON.stringify(bidData)); // Convert the bid data to a buffer.
-    statefulTxt.setTransient({ bid: transientMapData }); // Set the transient data.
+    statefulTxt.setTransient({ bid: bid }); // Pass the stored bid unchanged.
 
     // Set the endorsing orgs.
     if (auction.organizations.length === 2) {
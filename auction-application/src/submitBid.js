@@ -21,9 +21,10 @@ const myChaincodeName = 'auction-chaincode';
  * @param {string} user - The user.
  * @param {string} auctionID - The auction ID.
  * @param {string} bidID - The bid ID.
+ * @param {string} maxPrice - The maximum price of the bid, held from the token account.
  * @returns {Promise<void>}
  */
-async function submitBid(ccp, wallet, user, auctionID, bidID) {
+async function submitBid(ccp, wallet, user, auctionID, bidID, maxPrice) {
   try {
     // Create a new gateway for connecting to our peer node.
     const gateway = new Gateway();
@@ -60,7 +61,7 @@ async function submitBid(ccp, wallet, user, auctionID, bidID) {
     }
 
     console.log('\n-> Submit Transaction: add bid to the auction');
-    await statefulTxt.submit(auctionID, bidID);
+    await statefulTxt.submit(auctionID, bidID, maxPrice);
     console.log('\n*** Result: committed');
 
     // Evaluate the transaction.
@@ -79,7 +80,8 @@ async function submitBid(ccp, wallet, user, auctionID, bidID) {
 }
 
 // Argument list for the script.
-const fileAndArgs = 'submitBid.js <org> <userID> <auctionID> <bidID>';
+const fileAndArgs =
+  'submitBid.js <org> <userID> <auctionID> <bidID> <maxPrice>';
 
 /**
  * @description Submit a bid and submits it to the ledger.
@@ -92,13 +94,14 @@ async function main() {
         process.argv[2] === undefined ||
         process.argv[3] === undefined ||
         process.argv[4] === undefined ||
-        process.argv[5] === undefined,
+        process.argv[5] === undefined ||
+        process.argv[6] === undefined,
       fileAndArgs,
-      'Missing required arguments: org, userID, auctionID, bidID'
+      'Missing required arguments: org, userID, auctionID, bidID, maxPrice'
     );
 
     // Get all the arguments.
-    let [, , org, user, auctionID, bidID] = process.argv;
+    let [, , org, user, auctionID, bidID, maxPrice] = process.argv;
     checkArgs(
       /^(org1|Org1|org2|Org2)$/.test(org),
       fileAndArgs,
@@ -119,6 +122,11 @@ async function main() {
       fileAndArgs,
       'Bid ID must be a non-empty string'
     );
+    checkArgs(
+      /^[0-9]+$/.test(maxPrice),
+      fileAndArgs,
+      'Maximum price must be a non-empty string and must be a number'
+    );
 
     org = org.toLowerCase();
 
@@ -126,7 +134,7 @@ async function main() {
     const walletPath = path.join(__dirname, `wallet/${org}`);
     const wallet = await buildWallet(walletPath);
 
-    await submitBid(ccp, wallet, user, auctionID, bidID);
+    await submitBid(ccp, wallet, user, auctionID, bidID, maxPrice);
   } catch (error) {
     handleError('Failed to run the submit bid transaction: ', error);
   }
@@ -0,0 +1,110 @@
+'use strict';
+
+const path = require('path');
+const { Gateway } = require('fabric-network');
+
+const {
+  buildCCPOrg,
+  buildWallet,
+  checkArgs,
+  handleError,
+} = require('./utils/AppUtil');
+
+const myChannel = 'mychannel';
+const myChaincodeName = 'auction-chaincode';
+const myTokenContractName = 'token';
+
+/**
+ * @description Submits the transfer transaction to the ledger and evaluates the balance.
+ * @param {*} ccp - The common connection profile.
+ * @param {Wallet} wallet - The wallet.
+ * @param {string} user - The user.
+ * @param {string} recipient - The account ID of the recipient.
+ * @param {string} amount - The amount of tokens to transfer.
+ * @returns {Promise<void>}
+ */
+async function transfer(ccp, wallet, user, recipient, amount) {
+  try {
+    // Create a new gateway for connecting to our peer node.
+    const gateway = new Gateway();
+
+    // Connect using Discovery enabled.
+    await gateway.connect(ccp, {
+      wallet,
+      identity: user,
+      discovery: { enabled: true, asLocalhost: true },
+    });
+
+    // Get the token contract of the chaincode.
+    const network = await gateway.getNetwork(myChannel);
+    const contract = network.getContract(myChaincodeName, myTokenContractName);
+
+    console.log('\n-> Submit Transaction: Transfer tokens');
+    await contract.submitTransaction('Transfer', recipient, amount);
+    console.log('\n*** Result: committed');
+
+    // Evaluate the transaction.
+    console.log('\n--> Evaluate Transaction: Query the account balance');
+    let result = await contract.evaluateTransaction('ClientAccountBalance');
+    console.log(`\n*** Result: Balance: ${result.toString()}`);
+
+    // Disconnect from the gateway.
+    await gateway.disconnect();
+  } catch (error) {
+    console.error(`Failed to submit transfer transaction: ${error}`);
+  }
+}
+
+// Argument list for the script.
+const fileAndArgs = 'transfer.js <org> <userID> <recipientAccountID> <amount>';
+
+/**
+ * @description Transfer tokens to the account of another user. The account ID of
+ * a user is printed by the balance.js script.
+ */
+async function main() {
+  try {
+    // Check if the user has provided all the required inputs.
+    checkArgs(
+      process.argv.length >= 6,
+      fileAndArgs,
+      'Missing required arguments: org, userID, recipientAccountID, amount'
+    );
+
+    // Get all the arguments and validate them.
+    let [, , org, user, recipient, amount] = process.argv;
+    checkArgs(
+      /^(org1|Org1|org2|Org2)$/.test(org),
+      fileAndArgs,
+      'Org must be either org1 or Org1 or org2 or Org2'
+    );
+    checkArgs(
+      /^[a-zA-Z0-9]+$/.test(user),
+      fileAndArgs,
+      'User ID must be a non-empty string'
+    );
+    checkArgs(
+      recipient.length > 0,
+      fileAndArgs,
+      'Recipient account ID must be a non-empty string'
+    );
+    checkArgs(
+      /^[0-9]+$/.test(amount),
+      fileAndArgs,
+      'Amount must be a non-empty string and must be a number'
+    );
+
+    org = org.toLowerCase();
+
+    const ccp = buildCCPOrg(org);
+    const walletPath = path.join(__dirname, `wallet/${org}`);
+    const wallet = await buildWallet(walletPath);
+
+    await transfer(ccp, wallet, user, recipient, amount);
+  } catch (error) {
+    handleError('Failed to run the transfer', error);
+  }
+}
+
+// Execute the main function.
+main();
@@ -71,13 +71,16 @@ exports.enrollAdmin = async (caClient, wallet, orgMspId) => {
  * @param {string} orgMspId - The MSP ID of the organization.
  * @param {string} userId - The user ID.
  * @param {string} affiliation - The organization affiliation.
+ * @param {string} [roles] - The roles of the user, separated by commas, issued
+ * as the auction.role attribute of its certificate.
  */
 exports.registerAndEnrollUser = async (
   caClient,
   wallet,
   orgMspId,
   userId,
-  affiliation
+  affiliation,
+  roles
 ) => {
   try {
     // Check to see if we've already enrolled the user.
@@ -106,11 +109,16 @@ exports.registerAndEnrollUser = async (
 
     // Register the user, enroll the user, and import the new identity into the wallet.
     // if affiliation is specified by client, the affiliation value must be configured in CA.
+    // The roles are added to the enrollment certificate, where the chaincode reads them.
+    const attrs = roles
+      ? [{ name: 'auction.role', value: roles, ecert: true }]
+      : [];
     const secret = await caClient.register(
       {
         affiliation,
         enrollmentID: userId,
         role: 'client',
+        attrs,
       },
       adminUser
     );
@@ -0,0 +1,118 @@
+package contract
+
+import (
+	"sort"
+)
+
+// allocateUnits is an internal utility function to allocate the units of a
+// sealed-bid auction to its revealed bids. The bids that meet the reserve are
+// filled from the highest unit price down, so the last bid filled can get fewer
+// units than it asks for. It returns the allocations, the lowest unit price that
+// wins units, and the uniform clearing price paid by every winner. If units are
+// left once every bid is filled, any bid that meets the reserve wins units, so
+// the lowest winning price is just below the reserve. It also reports a tie when
+// the tie-break rule decided which of the bids at the lowest winning price got
+// the units.
+func allocateUnits(auction *Auction, reservePrice int) ([]Allocation, int, int, bool) {
+	// Sort the bids by unit price, so that every peer allocates the units in the
+	// same order.
+	bidKeys := []string{}
+	for bidKey := range auction.RevealedBids {
+		bidKeys = append(bidKeys, bidKey)
+	}
+	sortBidKeys(auction, bidKeys, true)
+
+	allocations := []Allocation{}
+	allocated := make(map[string]int)
+	remaining := auction.Quantity
+	lowestWinning := 0
+	highestLosing := 0
+	for _, bidKey := range bidKeys {
+		bid := auction.RevealedBids[bidKey]
+
+		units := 0
+		if bid.Price >= reservePrice && remaining > 0 {
+			units = bid.Quantity
+			if units > remaining {
+				units = remaining
+			}
+			remaining -= units
+			lowestWinning = bid.Price
+			allocated[bidKey] = units
+
+			allocations = append(allocations, Allocation{
+				BidKey:   bidKey,
+				Bidder:   bid.Bidder,
+				Org:      bid.Org,
+				Quantity: units,
+			})
+		}
+
+		// The units a bid does not win are losing bids at its unit price.
+		if units < bid.Quantity && bid.Price > highestLosing {
+			highestLosing = bid.Price
+		}
+	}
+
+	winningPrice := lowestWinning
+	if remaining > 0 {
+		winningPrice = reservePrice - 1
+	}
+
+	// Determine the clearing price according to the auction format. The winners
+	// of a second-price auction pay at least the reserve.
+	clearingPrice := lowestWinning
+	if auction.Format == secondPriceFormat {
+		clearingPrice = highestLosing
+		if clearingPrice < reservePrice {
+			clearingPrice = reservePrice
+		}
+	}
+
+	for i := range allocations {
+		allocations[i].Price = clearingPrice
+	}
+
+	// The bids at the lowest winning price are tied if they could not all be
+	// filled.
+	tied := 0
+	unfilled := false
+	for _, bidKey := range bidKeys {
+		bid := auction.RevealedBids[bidKey]
+		if len(allocations) > 0 && bid.Price == lowestWinning {
+			tied++
+			unfilled = unfilled || allocated[bidKey] < bid.Quantity
+		}
+	}
+
+	return allocations, winningPrice, clearingPrice, tied > 1 && unfilled
+}
+
+// sortBidKeys is an internal utility function to sort the keys of revealed bids
+// by unit price, from the highest or from the lowest price. Bids of the same
+// price are ordered with the tie-break rule of the auction: the bid submitted
+// first comes first, then the bid with the lowest key.
+func sortBidKeys(auction *Auction, bidKeys []string, highestFirst bool) {
+	sort.Slice(bidKeys, func(i, j int) bool {
+		bid1 := auction.RevealedBids[bidKeys[i]]
+		bid2 := auction.RevealedBids[bidKeys[j]]
+		if bid1.Price != bid2.Price {
+			return (bid1.Price > bid2.Price) == highestFirst
+		}
+
+		return bidComesFirst(auction.PrivateBids, bidKeys[i], bidKeys[j])
+	})
+}
+
+// bidComesFirst is an internal utility function that applies the tie-break rule
+// to two bids of the same price: the bid submitted first comes first, then the
+// bid with the lowest key.
+func bidComesFirst(bidders map[string]BidHash, bidKey1 string, bidKey2 string) bool {
+	submitted1 := bidders[bidKey1].Timestamp
+	submitted2 := bidders[bidKey2].Timestamp
+	if submitted1 != submitted2 {
+		return submitted1 < submitted2
+	}
+
+	return bidKey1 < bidKey2
+}
@@ -14,9 +14,38 @@ type AuctionContract struct {
 	contractapi.Contract
 }
 
-// CreateAuction creates on auction on the public channel. The identity that
-// submits the transaction becomes the seller of the auction.
-func (c *AuctionContract) CreateAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string) error {
+// CreateAuction creates a sealed-bid auction on the public channel. The identity
+// that submits the transaction becomes the seller of the auction, and the item
+// sold is locked until the auction ends or is cancelled. The deadlines are given
+// in seconds since the Unix epoch. The seller can commit to a hidden reserve
+// price under the reserve key of the transient map, and can restrict the auction
+// to a list of invitees.
+func (c *AuctionContract) CreateAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, format string, biddingDeadline int64, revealDeadline int64, deposit int, quantity int, invitees []string) error {
+	// Check that the auction format is supported.
+	if format != firstPriceFormat && format != secondPriceFormat {
+		return fmt.Errorf("Auction format must be %v or %v, got %v", firstPriceFormat, secondPriceFormat, format)
+	}
+
+	if deposit < 0 {
+		return fmt.Errorf("Deposit must not be negative, got %v", deposit)
+	}
+
+	if quantity <= 0 {
+		return fmt.Errorf("Quantity must be positive, got %v", quantity)
+	}
+
+	// Check that the deadlines are in the future and in order.
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+	if biddingDeadline <= now {
+		return fmt.Errorf("Bidding deadline %v must be after the transaction timestamp %v", biddingDeadline, now)
+	}
+	if revealDeadline <= biddingDeadline {
+		return fmt.Errorf("Reveal deadline %v must be after the bidding deadline %v", revealDeadline, biddingDeadline)
+	}
+
 	// Get ID of submitting client identity.
 	clientID, err := c.GetSubmittingClientIdentity(ctx)
 	if err != nil {
@@ -29,59 +58,183 @@ func (c *AuctionContract) CreateAuction(ctx contractapi.TransactionContextInterf
 		return fmt.Errorf("Failed to get Org client identity: %v", err)
 	}
 
-	// Create auction object.
-	bidders := make(map[string]BidHash)
-	revealedBids := make(map[string]FullBid)
+	// Get the optional reserve price from the transient map.
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("Error getting reserve from transient map: %v", err)
+	}
+
+	reserveHash := ""
+	if transientReserve, ok := transientMap["reserve"]; ok {
+		var reserve Reserve
+
+		err = json.Unmarshal(transientReserve, &reserve)
+		if err != nil {
+			return fmt.Errorf("Failed to unmarshal reserve: %v", err)
+		}
+		if reserve.Price <= 0 {
+			return fmt.Errorf("Reserve price must be positive, got %v", reserve.Price)
+		}
+		if reserve.Seller != clientID {
+			return fmt.Errorf("Permission denied, client id %v is not the seller of the reserve", clientID)
+		}
+		err = validateSalt(reserve.Salt)
+		if err != nil {
+			return fmt.Errorf("Invalid reserve: %v", err)
+		}
+
+		// The seller has to target their peer to store the reserve.
+		err = verifyClientOrgMatchesPeerOrg(ctx)
+		if err != nil {
+			return fmt.Errorf("Cannot store reserve on this peer, not a member of this org: %v", err)
+		}
+
+		// Get the implicit collection name using the seller's organization ID.
+		collection, err := getCollectionName(ctx)
+		if err != nil {
+			return fmt.Errorf("Failed to get implicit collection name: %v", err)
+		}
 
+		reserveKey, err := ctx.GetStub().CreateCompositeKey(reserveKeyType, []string{auctionID})
+		if err != nil {
+			return fmt.Errorf("Failed to create composite key: %v", err)
+		}
+
+		// Put the reserve into the organization's implicit data collection.
+		err = ctx.GetStub().PutPrivateData(collection, reserveKey, transientReserve)
+		if err != nil {
+			return fmt.Errorf("Failed to input reserve into collection: %v", err)
+		}
+
+		// The hash matches the private data hash of the reserve in the collection.
+		hash := sha256.Sum256(transientReserve)
+		reserveHash = fmt.Sprintf("%x", hash)
+	}
+
+	err = validateInvitees(invitees)
+	if err != nil {
+		return err
+	}
+
+	// Create auction object. The bids are stored under their own keys.
 	auction := Auction{
-		Type:         "auction",
-		ItemSold:     itemsold,
-		Price:        0,
-		Seller:       clientID,
-		Orgs:         []string{clientOrgID},
-		PrivateBids:  bidders,
-		RevealedBids: revealedBids,
-		Winner:       "",
-		Status:       "open",
+		Type:            "auction",
+		ID:              auctionID,
+		ItemSold:        itemsold,
+		Quantity:        quantity,
+		Format:          format,
+		Price:           0,
+		Seller:          clientID,
+		ReserveHash:     reserveHash,
+		Orgs:            []string{clientOrgID},
+		Winner:          "",
+		WinningBid:      0,
+		Status:          "open",
+		BiddingDeadline: biddingDeadline,
+		RevealDeadline:  revealDeadline,
+		Deposit:         deposit,
+		TieBreak:        tieBreakRule,
+		InviteOnly:      len(invitees) > 0,
+		Invitees:        invitees,
 	}
 
-	bytes, err := json.Marshal(auction)
+	return putNewAuction(ctx, &auction)
+}
+
+// putNewAuction is an internal utility function to store an auction that is
+// created, after locking its item. The organization of the seller becomes the
+// endorser of the auction.
+func putNewAuction(ctx contractapi.TransactionContextInterface, auction *Auction) error {
+	// Check that the auction does not exist yet.
+	existing, err := ctx.GetStub().GetState(auction.ID)
 	if err != nil {
-		return fmt.Errorf("Failed to marshal auction object: %v", err)
+		return fmt.Errorf("Failed to get auction object %v: %v", auction.ID, err)
+	}
+	if existing != nil {
+		return fmt.Errorf("Auction %v already exists", auction.ID)
+	}
+
+	// Lock the item of the seller for the auction. The units traded on a double
+	// auction are not registered items.
+	if auction.Format != doubleFormat {
+		err = lockItem(ctx, auction.ItemSold, auction.Seller, auction.ID)
+		if err != nil {
+			return fmt.Errorf("Cannot sell item %v: %v", auction.ItemSold, err)
+		}
 	}
 
 	// Store auction object into state.
-	err = ctx.GetStub().PutState(auctionID, bytes)
+	err = putAuction(ctx, auction.ID, auction)
 	if err != nil {
 		return fmt.Errorf("Failed to put auction in public data: %v", err)
 	}
 
+	// Index the auction by status, seller and item sold.
+	err = putAuctionIndexes(ctx, auction.ID, auction)
+	if err != nil {
+		return fmt.Errorf("Failed to index auction: %v", err)
+	}
+
 	// Set the seller of the auction as an endorser.
-	err = setAssetStateBasedEndorsement(ctx, auctionID, clientOrgID)
+	err = setAssetStateBasedEndorsement(ctx, auction.ID, auction.Orgs...)
 	if err != nil {
 		return fmt.Errorf("Failed setting state based endorsement for new organization: %v", err)
 	}
 
+	// Notify clients that the auction was created.
+	err = setAuctionEvent(ctx, AuctionCreatedEvent, AuctionEvent{AuctionID: auction.ID, Status: auction.Status})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
-// QueryAuction allows all members of the channel to read a public auction.
+// QueryAuction allows all members of the channel to read a public auction. The
+// private and revealed bids of the auction are read from their own keys. The
+// price of an open dutch auction is the current price of its schedule. The
+// invitees of an invite-only auction are only shown to the seller and to the
+// invitees.
 func (c *AuctionContract) QueryAuction(ctx contractapi.TransactionContextInterface, auctionID string) (*Auction, error) {
-	// Get Auction from the ledger.
-	bytes, err := ctx.GetStub().GetState(auctionID)
+	auction, err := getAuctionWithBids(ctx, auctionID)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to get auction object %v: %v", auctionID, err)
+		return nil, err
 	}
-	if bytes == nil {
-		return nil, fmt.Errorf("Auction %v does not exist", auctionID)
+
+	err = hideInvitees(ctx, auction)
+	if err != nil {
+		return nil, err
+	}
+
+	return auction, nil
+}
+
+// getAuctionWithBids is an internal utility function to read an auction along
+// with its private and revealed bids.
+func getAuctionWithBids(ctx contractapi.TransactionContextInterface, auctionID string) (*Auction, error) {
+	// Get Auction from the ledger.
+	auction, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return nil, err
 	}
 
-	auction := new(Auction)
+	if auction.Format == dutchFormat && auction.Status == "open" {
+		now, err := getTxTimestamp(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get transaction timestamp: %v", err)
+		}
 
-	err = json.Unmarshal(bytes, auction)
+		auction.Price = dutchPrice(auction, now)
+	}
+
+	auction.PrivateBids, err = getPrivateBids(ctx, auctionID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get private bids of auction %v: %v", auctionID, err)
+	}
 
+	auction.RevealedBids, err = getRevealedBids(ctx, auctionID)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to unmarshal auction object %v: %v", auctionID, err)
+		return nil, fmt.Errorf("Failed to get revealed bids of auction %v: %v", auctionID, err)
 	}
 
 	return auction, nil
@@ -89,7 +242,11 @@ func (c *AuctionContract) QueryAuction(ctx contractapi.TransactionContextInterfa
 
 // Bid is used to add a user's bid to the auction. The bid is stored in the private
 // data collection on the peer of the bidder's organization. The function returns
-// the transaction ID so that users can identify and query their bid.
+// the transaction ID so that users can identify and query their bid. The bid has
+// to carry a random salt, so that the price cannot be guessed from the hash of
+// the bid that is published when the bid is submitted. The bid is rejected unless
+// it belongs to the submitting client and their organization, has a positive
+// price, and the auction is open for bids.
 func (c *AuctionContract) CreateBid(ctx contractapi.TransactionContextInterface, auctionID string) (string, error) {
 	// Get Bid from transient map.
 	transientMap, err := ctx.GetStub().GetTransient()
@@ -102,6 +259,83 @@ func (c *AuctionContract) CreateBid(ctx contractapi.TransactionContextInterface,
 		return "", fmt.Errorf("Bid key not found in the transient map")
 	}
 
+	// Validate the bid before it is stored, a bid that cannot be revealed would
+	// otherwise only be discovered after the auction is closed.
+	bidInput, err := parseTransientBid(bid)
+	if err != nil {
+		return "", fmt.Errorf("Invalid bid: %v", err)
+	}
+
+	// Check that the bid is salted.
+	err = validateSalt(bidInput.Salt)
+	if err != nil {
+		return "", fmt.Errorf("Invalid bid salt: %v", err)
+	}
+
+	// Check that the price of the bid is positive.
+	if bidInput.Price <= 0 {
+		return "", fmt.Errorf("Bid price must be positive, got %v", bidInput.Price)
+	}
+
+	// Check that the bid belongs to the organization of the bidder.
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", fmt.Errorf("Failed to get client identity MSP ID: %v", err)
+	}
+	if bidInput.Org != clientOrgID {
+		return "", fmt.Errorf("Bid org %v does not match client org %v", bidInput.Org, clientOrgID)
+	}
+
+	// Check that the bid belongs to the submitting client identity.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+	if bidInput.Bidder != clientID {
+		return "", fmt.Errorf("Permission denied, client id %v is not the owner of the bid", clientID)
+	}
+
+	// Check that the auction exists and accepts bids.
+	auction, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get auction from public state: %v", err)
+	}
+	if auction.Status != "open" {
+		return "", fmt.Errorf("Cannot bid on closed or ended auction")
+	}
+	if auction.Format == dutchFormat {
+		return "", fmt.Errorf("Cannot bid on dutch auction, the price is accepted with AcceptPrice")
+	}
+	if auction.Format == englishFormat {
+		return "", fmt.Errorf("Cannot bid on english auction, bids are placed with PlaceOpenBid")
+	}
+
+	// Check that the bid asks for units that the auction sells. The orders of a
+	// double auction can trade any number of units, and must have a side.
+	if auction.Format == doubleFormat {
+		if bidInput.Quantity <= 0 {
+			return "", fmt.Errorf("Order quantity must be positive, got %v", bidInput.Quantity)
+		}
+		if bidInput.Side != bidSide && bidInput.Side != askSide {
+			return "", fmt.Errorf("Order side must be %v or %v, got %v", bidSide, askSide, bidInput.Side)
+		}
+	} else {
+		if bidInput.Quantity <= 0 || bidInput.Quantity > auction.Quantity {
+			return "", fmt.Errorf("Bid quantity must be between 1 and the %v units of the auction, got %v", auction.Quantity, bidInput.Quantity)
+		}
+		if bidInput.Side != "" {
+			return "", fmt.Errorf("Only the orders of a double auction have a side, got %v", bidInput.Side)
+		}
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+	if now > auction.BiddingDeadline {
+		return "", fmt.Errorf("Cannot bid on auction, bidding deadline %v has passed", auction.BiddingDeadline)
+	}
+
 	// Get the implicit collection name using the bidder's organization ID.
 	collection, err := getCollectionName(ctx)
 	if err != nil {
@@ -135,37 +369,58 @@ func (c *AuctionContract) CreateBid(ctx contractapi.TransactionContextInterface,
 
 // QueryBid allows the submitter of the bid to query their bid from public state.
 func (c *AuctionContract) QueryBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string) (*FullBid, error) {
+	bid, _, err := getOwnBid(ctx, auctionID, txID)
+
+	return bid, err
+}
+
+// QueryStoredBid allows the submitter of the bid to query their bid as it is
+// stored in the private data collection, byte for byte. Passing it unchanged to
+// RevealBid or WithdrawBid ensures that it matches the hash of the bid.
+func (c *AuctionContract) QueryStoredBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string) (string, error) {
+	_, bytes, err := getOwnBid(ctx, auctionID, txID)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
+
+// getOwnBid is an internal utility function to read a bid of the submitting
+// client from the implicit data collection of its organization. It returns the
+// bid along with the stored bytes of the bid.
+func getOwnBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string) (*FullBid, []byte, error) {
 	// Verify that the bidder is a member of the bidder's organization.
 	err := verifyClientOrgMatchesPeerOrg(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to verify that the bidder is a member of the bidder's organization': %v", err)
+		return nil, nil, fmt.Errorf("Failed to verify that the bidder is a member of the bidder's organization': %v", err)
 	}
 
 	// Get ID of submitting client identity.
-	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	clientID, err := getSubmittingClientID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to get ID of the client identity: %v", err)
+		return nil, nil, fmt.Errorf("Failed to get ID of the client identity: %v", err)
 	}
 
 	// Get the implicit collection name of bidder's org.
 	collection, err := getCollectionName(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to get implicit collection name: %v", err)
+		return nil, nil, fmt.Errorf("Failed to get implicit collection name: %v", err)
 	}
 
 	// Create a composite key using the auction ID and transaction ID.
 	bidKey, err := ctx.GetStub().CreateCompositeKey(bidKeyType, []string{auctionID, txID})
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create composite key: %v", err)
+		return nil, nil, fmt.Errorf("Failed to create composite key: %v", err)
 	}
 
 	// Get the bid from the bidder's org's private data collection.
 	bytes, err := ctx.GetStub().GetPrivateData(collection, bidKey)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to get bid %v from collection: %v", bidKey, err)
+		return nil, nil, fmt.Errorf("Failed to get bid %v from collection: %v", bidKey, err)
 	}
 	if bytes == nil {
-		return nil, fmt.Errorf("Bid key %v does not exist in the collection", bidKey)
+		return nil, nil, fmt.Errorf("Bid key %v does not exist in the collection", bidKey)
 	}
 
 	// Unmarshal the bid into a FullBid object.
@@ -173,39 +428,168 @@ func (c *AuctionContract) QueryBid(ctx contractapi.TransactionContextInterface,
 
 	err = json.Unmarshal(bytes, &bid)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to unmarshal bid: %v", err)
+		return nil, nil, fmt.Errorf("Failed to unmarshal bid: %v", err)
 	}
 
 	// Check that the client querying the bid is the bid owner.
 	if bid.Bidder != clientID {
-		return nil, fmt.Errorf("Permission denied, client id %v is not the owner of the bid", clientID)
+		return nil, nil, fmt.Errorf("Permission denied, client id %v is not the owner of the bid", clientID)
+	}
+
+	return bid, bytes, nil
+}
+
+// QueryReserve allows the seller to query the reserve price of their auction from
+// the implicit data collection of their organization.
+func (c *AuctionContract) QueryReserve(ctx contractapi.TransactionContextInterface, auctionID string) (*Reserve, error) {
+	// Get ID of submitting client identity.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
+	reserve, _, err := getStoredReserve(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check that the client querying the reserve is the seller.
+	if reserve.Seller != clientID {
+		return nil, fmt.Errorf("Permission denied, client id %v is not the seller of the auction", clientID)
 	}
 
-	return bid, nil
+	return reserve, nil
+}
+
+// QueryStoredReserve allows the seller, or an auctioneer of the seller's
+// organization, to query the reserve of an auction as it is stored in the
+// private data collection, byte for byte. Passing it unchanged to EndAuction
+// ensures that it matches the hash of the reserve.
+func (c *AuctionContract) QueryStoredReserve(ctx contractapi.TransactionContextInterface, auctionID string) (string, error) {
+	auction, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get auction from public state: %v", err)
+	}
+
+	// Get ID of submitting client identity.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
+	forSeller, err := actsForSeller(ctx, auction, clientID)
+	if err != nil {
+		return "", err
+	}
+	if !forSeller {
+		return "", fmt.Errorf("Permission denied, client id %v does not act for the seller of the auction", clientID)
+	}
+
+	_, bytes, err := getStoredReserve(ctx, auctionID)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
+
+// getStoredReserve is an internal utility function to read the reserve of an
+// auction from the implicit data collection of the organization of the client.
+// It returns the reserve along with the stored bytes of the reserve.
+func getStoredReserve(ctx contractapi.TransactionContextInterface, auctionID string) (*Reserve, []byte, error) {
+	// Verify that the client is a member of the peer's organization.
+	err := verifyClientOrgMatchesPeerOrg(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to verify that the seller is a member of the seller's organization: %v", err)
+	}
+
+	// Get the implicit collection name of seller's org.
+	collection, err := getCollectionName(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to get implicit collection name: %v", err)
+	}
+
+	// Create a composite key using the auction ID.
+	reserveKey, err := ctx.GetStub().CreateCompositeKey(reserveKeyType, []string{auctionID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	// Get the reserve from the seller's org's private data collection.
+	bytes, err := ctx.GetStub().GetPrivateData(collection, reserveKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to get reserve %v from collection: %v", reserveKey, err)
+	}
+	if bytes == nil {
+		return nil, nil, fmt.Errorf("Reserve key %v does not exist in the collection", reserveKey)
+	}
+
+	var reserve *Reserve
+
+	err = json.Unmarshal(bytes, &reserve)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to unmarshal reserve: %v", err)
+	}
+
+	return reserve, bytes, nil
 }
 
 // SubmitBid is used by the bidder to add the hash of that bid stored in private data to the
-// auction. Note that this function alters the auction in private state, and needs
-// to meet the auction endorsement policy. Transaction ID is used identify the bid.
-func (c *AuctionContract) SubmitBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string) error {
+// auction. The hash is stored under its own key, so that bidders do not conflict
+// with each other. The auction is only updated, and needs to meet the auction
+// endorsement policy, when the first bid of an organization is submitted.
+// Transaction ID is used identify the bid. The bidder declares the maximum price
+// of the bid, and that many tokens are held from their account until the auction
+// ends, so that the winner can pay without the bid being revealed early. The
+// deposit of the auction is held along with it.
+func (c *AuctionContract) SubmitBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string, maxPrice int) error {
 	// Get the MSP ID of the bidder's org.
 	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
 		return fmt.Errorf("Failed to get client identity MSP ID: %v", err)
 	}
 
+	// Get ID of submitting client identity.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
 	// Get the auction from public state.
-	auction, err := c.QueryAuction(ctx, auctionID)
+	auction, err := getAuction(ctx, auctionID)
 	if err != nil {
 		return fmt.Errorf("Failed to get auction from public state: %v", err)
 	}
 
+	// The sell orders of a double auction are paid rather than pay, so they only
+	// hold the deposit. Every order has to stake tokens.
+	if maxPrice < 0 || (maxPrice == 0 && auction.Format != doubleFormat) {
+		return fmt.Errorf("Maximum price must be positive, got %v", maxPrice)
+	}
+	if maxPrice+auction.Deposit <= 0 {
+		return fmt.Errorf("Order must hold a positive amount, got a maximum price of %v and a deposit of %v", maxPrice, auction.Deposit)
+	}
+
 	// The auction needs to be open for users to add their bid.
 	Status := auction.Status
 	if Status != "open" {
 		return fmt.Errorf("Cannot join closed or ended auction")
 	}
 
+	// Bids cannot be added once the bidding deadline has passed.
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+	if now > auction.BiddingDeadline {
+		return fmt.Errorf("Cannot join auction, bidding deadline %v has passed", auction.BiddingDeadline)
+	}
+
+	// Only invitees can bid on an invite-only auction.
+	if !isInvited(auction, clientID, clientOrgID) {
+		return fmt.Errorf("Permission denied, client %v of %v is not invited to auction %v", clientID, clientOrgID, auctionID)
+	}
+
 	// Get the implicit collection name of bidder's org.
 	collection, err := getCollectionName(ctx)
 	if err != nil {
@@ -227,36 +611,71 @@ func (c *AuctionContract) SubmitBid(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("Bid Hash does not exist in private data collection: %s", bidKey)
 	}
 
-	// Store the hash along with the bidder's organization.
-	NewBidHash := BidHash{
-		Org:  clientOrgID,
-		Hash: fmt.Sprintf("%x", bidHash),
+	// A bid can only be submitted once, or its tokens would be held twice.
+	privateBid, err := getPrivateBid(ctx, auctionID, txID)
+	if err != nil {
+		return fmt.Errorf("Failed to get private bid from public state: %v", err)
+	}
+	if privateBid != nil {
+		return fmt.Errorf("Bid %s was already submitted to the auction", bidKey)
 	}
 
-	// Add the bid hash to the auction bidders hash.
-	bidders := make(map[string]BidHash)
-	bidders = auction.PrivateBids
-	bidders[bidKey] = NewBidHash
-	auction.PrivateBids = bidders
+	// Store the hash along with the bidder's organization. The timestamp of the
+	// submission breaks ties between bids of the same price.
+	NewBidHash := BidHash{
+		Org:       clientOrgID,
+		Hash:      fmt.Sprintf("%x", bidHash),
+		Timestamp: now,
+	}
 
 	// Add the bidding organization to the list of participating organizations if it is not already.
 	Orgs := auction.Orgs
 	if !contains(Orgs, clientOrgID) {
-		newOrgs := append(Orgs, clientOrgID)
-		auction.Orgs = newOrgs
+		err = addAuctionOrg(ctx, auction, clientOrgID)
+		if err != nil {
+			return err
+		}
 
-		err = addAssetStateBasedEndorsement(ctx, auctionID, clientOrgID)
+		// Update the auction in public state.
+		err = putAuction(ctx, auctionID, auction)
 		if err != nil {
-			return fmt.Errorf("Failed setting state based endorsement for new organization: %v", err)
+			return fmt.Errorf("Failed to update auction state: %v", err)
 		}
 	}
 
-	newAuction, _ := json.Marshal(auction)
+	// Add the bid hash to the auction under its own key.
+	privateBidKey, err := ctx.GetStub().CreateCompositeKey(privateBidKeyType, []string{auctionID, txID})
+	if err != nil {
+		return fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	bidHashBytes, _ := json.Marshal(NewBidHash)
+
+	err = ctx.GetStub().PutState(privateBidKey, bidHashBytes)
+	if err != nil {
+		return fmt.Errorf("Failed to put private bid in public data: %v", err)
+	}
+
+	// Updates to the bid need to be endorsed by the organizations of the seller
+	// and of the bidder.
+	endorsers := bidEndorsers(auction, clientOrgID)
+
+	err = setAssetStateBasedEndorsement(ctx, privateBidKey, endorsers...)
+	if err != nil {
+		return fmt.Errorf("Failed setting state based endorsement for private bid: %v", err)
+	}
+
+	// Hold the maximum price of the bid and the deposit from the account of the
+	// bidder.
+	err = placeHold(ctx, auctionID, txID, Hold{Holder: clientID, Amount: maxPrice, Deposit: auction.Deposit}, endorsers)
+	if err != nil {
+		return fmt.Errorf("Failed to hold tokens for bid: %v", err)
+	}
 
-	// Update the auction in private state.
-	err = ctx.GetStub().PutState(auctionID, newAuction)
+	// Notify clients that a bid was submitted.
+	err = setAuctionEvent(ctx, BidSubmittedEvent, AuctionEvent{AuctionID: auctionID, Status: auction.Status, BidKey: bidKey})
 	if err != nil {
-		return fmt.Errorf("Failed to update auction state: %v", err)
+		return err
 	}
 
 	return nil
@@ -297,7 +716,7 @@ func (c *AuctionContract) RevealBid(ctx contractapi.TransactionContextInterface,
 	}
 
 	// Get auction from public state
-	auction, err := c.QueryAuction(ctx, auctionID)
+	auction, err := getAuction(ctx, auctionID)
 	if err != nil {
 		return fmt.Errorf("Failed to get auction from public state: %v", err)
 	}
@@ -311,6 +730,15 @@ func (c *AuctionContract) RevealBid(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("Cannot reveal bid for open or ended auction")
 	}
 
+	// Bids cannot be revealed once the reveal deadline has passed.
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+	if now > auction.RevealDeadline {
+		return fmt.Errorf("Cannot reveal bid, reveal deadline %v has passed", auction.RevealDeadline)
+	}
+
 	// Check 2: check that hash of revealed bid matches hash of private bid
 	// on the public ledger. This checks that the bidder is telling the truth
 	// about the value of their bid.
@@ -330,8 +758,14 @@ func (c *AuctionContract) RevealBid(ctx contractapi.TransactionContextInterface,
 	// Check 3: check hash of revealed bid matches hash of private bid that was
 	// added earlier. This ensures that the bid has not changed since it
 	// was added to the auction.
-	bidders := auction.PrivateBids
-	privateBidHashString := bidders[bidKey].Hash
+	privateBid, err := getPrivateBid(ctx, auctionID, txID)
+	if err != nil {
+		return fmt.Errorf("Failed to get private bid from public state: %v", err)
+	}
+	if privateBid == nil {
+		return fmt.Errorf("Bid %s was not submitted to the auction", bidKey)
+	}
+	privateBidHashString := privateBid.Hash
 
 	onChainBidHashString := fmt.Sprintf("%x", bidHash)
 	if privateBidHashString != onChainBidHashString {
@@ -344,9 +778,12 @@ func (c *AuctionContract) RevealBid(ctx contractapi.TransactionContextInterface,
 
 	// We can add the bid to the auction if all checks have passed.
 	type transientBidInput struct {
-		Price  int    `json:"price"`
-		Org    string `json:"org"`
-		Bidder string `json:"bidder"`
+		Price    int    `json:"price"`
+		Quantity int    `json:"quantity"`
+		Side     string `json:"side"`
+		Org      string `json:"org"`
+		Bidder   string `json:"bidder"`
+		Salt     string `json:"salt"`
 	}
 
 	// Unmarhsal the bid into a transientBidInput struct.
@@ -357,18 +794,28 @@ func (c *AuctionContract) RevealBid(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("Failed to unmarshal bid: %v", err)
 	}
 
+	// The salt is covered by the hash checks above, but it must still be a
+	// valid salt.
+	err = validateSalt(bidInput.Salt)
+	if err != nil {
+		return fmt.Errorf("Invalid bid salt: %v", err)
+	}
+
 	// Get ID of submitting client identity.
 	clientID, err := c.GetSubmittingClientIdentity(ctx)
 	if err != nil {
 		return fmt.Errorf("Failed to get submitting client identity: %v", err)
 	}
 
-	// Marshal transient parameters and ID and MSP ID into bid object.
+	// Marshal transient parameters and ID and MSP ID into bid object. The salt
+	// is not copied, it must never be published with the revealed bid.
 	NewBid := FullBid{
-		Type:   bidKeyType,
-		Price:  bidInput.Price,
-		Org:    bidInput.Org,
-		Bidder: bidInput.Bidder,
+		Type:     bidKeyType,
+		Price:    bidInput.Price,
+		Quantity: bidInput.Quantity,
+		Side:     bidInput.Side,
+		Org:      bidInput.Org,
+		Bidder:   bidInput.Bidder,
 	}
 
 	// Check 4: make sure that the transaction is being submitted is the bidder.
@@ -376,34 +823,71 @@ func (c *AuctionContract) RevealBid(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("Permission denied, client id %v is not the owner of the bid", clientID)
 	}
 
-	// Add the bid to the auction.
-	revealedBids := make(map[string]FullBid)
-	revealedBids = auction.RevealedBids
-	revealedBids[bidKey] = NewBid
-	auction.RevealedBids = revealedBids
+	// Check 5: make sure that the tokens held for the bid can pay for it. The sell
+	// orders of a double auction do not pay.
+	holds, err := getHolds(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("Failed to get holds of auction %v: %v", auctionID, err)
+	}
+	if bidInput.Side != askSide && !holdCoversBid(holds[bidKey], bidInput.Price, bidInput.Quantity) {
+		return fmt.Errorf("Bid of %v units at %v exceeds the %v tokens held for the bid", bidInput.Quantity, bidInput.Price, holds[bidKey].Amount)
+	}
 
-	// Update the auction in state.
-	newAuction, _ := json.Marshal(auction)
+	// Add the bid to the auction under its own key.
+	revealedBidKey, err := ctx.GetStub().CreateCompositeKey(revealedBidKeyType, []string{auctionID, txID})
+	if err != nil {
+		return fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	revealedBid, _ := json.Marshal(NewBid)
+
+	err = ctx.GetStub().PutState(revealedBidKey, revealedBid)
+	if err != nil {
+		return fmt.Errorf("Failed to put revealed bid in public data: %v", err)
+	}
+
+	// The auction is closed, so its organizations no longer change, and updates
+	// to the revealed bid need to be endorsed by all of them.
+	err = setAssetStateBasedEndorsement(ctx, revealedBidKey, auction.Orgs...)
+	if err != nil {
+		return fmt.Errorf("Failed setting state based endorsement for revealed bid: %v", err)
+	}
+
+	// A new key is only covered by the chaincode policy, so the endorsement
+	// policy of the submitted bid is set again, unchanged. Setting it needs the
+	// endorsement of the organizations of the seller and of the bidder.
+	privateBidKey, err := ctx.GetStub().CreateCompositeKey(privateBidKeyType, []string{auctionID, txID})
+	if err != nil {
+		return fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	err = setAssetStateBasedEndorsement(ctx, privateBidKey, bidEndorsers(auction, privateBid.Org)...)
+	if err != nil {
+		return fmt.Errorf("Failed setting state based endorsement for private bid: %v", err)
+	}
 
-	// Put auction with bid added back into state.
-	err = ctx.GetStub().PutState(auctionID, newAuction)
+	// Notify clients that a bid was revealed.
+	err = setAuctionEvent(ctx, BidRevealedEvent, AuctionEvent{AuctionID: auctionID, Status: auction.Status, BidKey: bidKey})
 	if err != nil {
-		return fmt.Errorf("Failed to update auction state: %v", err)
+		return err
 	}
 
 	return nil
 }
 
 // CloseAuction can be used by the seller to close the auction. This prevents bids
-// from being added to the auction, and allows users to reveal their bid.
+// from being added to the auction, and allows users to reveal their bid. An
+// auctioneer of the seller's organization can close the auction for the seller.
+// Once the bidding deadline has passed any channel member can close the auction.
 func (c *AuctionContract) CloseAuction(ctx contractapi.TransactionContextInterface, auctionID string) error {
 	// Get auction from public state.
-	auction, err := c.QueryAuction(ctx, auctionID)
+	auction, err := getAuction(ctx, auctionID)
 	if err != nil {
 		return fmt.Errorf("Failed to get auction from public state: %v", err)
 	}
 
-	// The auction can only be closed by the seller.
+	// The auction can be closed by the seller or an auctioneer, or by anyone once
+	// the bidding deadline has passed.
 
 	// Get ID of submitting client identity.
 	clientID, err := c.GetSubmittingClientIdentity(ctx)
@@ -411,9 +895,17 @@ func (c *AuctionContract) CloseAuction(ctx contractapi.TransactionContextInterfa
 		return fmt.Errorf("Failed to get submitting client identity: %v", err)
 	}
 
-	Seller := auction.Seller
-	if Seller != clientID {
-		return fmt.Errorf("Auction can only be closed by seller: %v", err)
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+
+	forSeller, err := actsForSeller(ctx, auction, clientID)
+	if err != nil {
+		return err
+	}
+	if !forSeller && now <= auction.BiddingDeadline {
+		return fmt.Errorf("Auction can only be closed by seller or auctioneer before the bidding deadline %v", auction.BiddingDeadline)
 	}
 
 	// Check if auction is already closed.
@@ -421,31 +913,50 @@ func (c *AuctionContract) CloseAuction(ctx contractapi.TransactionContextInterfa
 	if Status != "open" {
 		return fmt.Errorf("Cannot close auction that is not open")
 	}
+	if auction.Format == dutchFormat {
+		return fmt.Errorf("Cannot close dutch auction, it ends when a buyer accepts the price")
+	}
+	if auction.Format == englishFormat && now <= auction.BiddingDeadline {
+		return fmt.Errorf("Cannot close english auction before its deadline %v", auction.BiddingDeadline)
+	}
 
 	// Change status of auction to closed.
 	auction.Status = string("closed")
 
-	// Update the auction in state.
-	closedAuction, _ := json.Marshal(auction)
+	err = updateAuctionStatusIndex(ctx, auctionID, Status, auction.Status)
+	if err != nil {
+		return fmt.Errorf("Failed to update auction status index: %v", err)
+	}
 
-	err = ctx.GetStub().PutState(auctionID, closedAuction)
+	// Update the auction in state.
+	err = putAuction(ctx, auctionID, auction)
 	if err != nil {
 		return fmt.Errorf("Failed to close auction: %v", err)
 	}
 
+	// Notify clients that the auction was closed.
+	err = setAuctionEvent(ctx, AuctionClosedEvent, AuctionEvent{AuctionID: auctionID, Status: auction.Status})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
-// EndAuction both changes the auction status to closed, and reveals the winning bid
-// of the auction.
+// EndAuction ends a closed auction, allocates its units to the highest revealed
+// bids, and settles the tokens held for the bids. The seller, or an auctioneer of
+// the seller's organization, reveals the reserve of the auction under the reserve
+// key of the transient map. Once the reveal deadline has passed, any channel
+// member can end the auction.
 func (c *AuctionContract) EndAuction(ctx contractapi.TransactionContextInterface, auctionID string) error {
 	// Get auction from public state.
-	auction, err := c.QueryAuction(ctx, auctionID)
+	auction, err := getAuctionWithBids(ctx, auctionID)
 	if err != nil {
 		return fmt.Errorf("Failed to get auction from public state: %v", err)
 	}
 
-	// Check that the auction is being ended by the seller.
+	// Check that the auction is being ended by the seller or an auctioneer, or by
+	// anyone once the reveal deadline has passed.
 
 	// Get ID of submitting client identity.
 	clientID, err := c.GetSubmittingClientIdentity(ctx)
@@ -453,13 +964,31 @@ func (c *AuctionContract) EndAuction(ctx contractapi.TransactionContextInterface
 		return fmt.Errorf("Failed to get submitting client identity: %v", err)
 	}
 
-	Seller := auction.Seller
-	if Seller != clientID {
-		return fmt.Errorf("Auction can only be ended by seller: %v", err)
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp: %v", err)
 	}
 
-	// Check if auction is already closed.
+	forSeller, err := actsForSeller(ctx, auction, clientID)
+	if err != nil {
+		return err
+	}
+	if !forSeller && now <= auction.RevealDeadline {
+		return fmt.Errorf("Auction can only be ended by seller or auctioneer before the reveal deadline %v", auction.RevealDeadline)
+	}
+
+	// Check if auction is already closed. English auctions have no reveal phase,
+	// and can be ended while open.
 	Status := auction.Status
+	if auction.Format == englishFormat && (Status == "open" || Status == "closed") {
+		return endEnglishAuction(ctx, auction, now)
+	}
+	if auction.Format == dutchFormat && Status == "open" {
+		return endExpiredDutchAuction(ctx, auction, now)
+	}
+	if auction.Format == doubleFormat {
+		return fmt.Errorf("Cannot end double auction, it is cleared with ClearAuction")
+	}
 	if Status != "closed" {
 		return fmt.Errorf("Cannot end auction that is not closed")
 	}
@@ -469,33 +998,354 @@ func (c *AuctionContract) EndAuction(ctx contractapi.TransactionContextInterface
 
 	// Check if there are any revealed bids in the auction.
 	if len(auction.RevealedBids) == 0 {
-		return fmt.Errorf("No bids have been revealed, cannot end auction: %v", err)
+		return fmt.Errorf("Cannot end auction %v, no bids have been revealed", auctionID)
 	}
 
-	// Determine the highest bid.
-	for _, bid := range revealedBids {
-		if bid.Price > auction.Price {
-			auction.Price = bid.Price
-			auction.Winner = bid.Bidder
+	// Get the reserve price revealed by the seller, if the auction has one.
+	reservePrice, err := revealReserve(ctx, auction, now)
+	if err != nil {
+		return fmt.Errorf("Failed to reveal reserve, cannot end auction: %v", err)
+	}
+
+	// Get the tokens held for the bids of the auction.
+	holds, err := getHolds(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("Failed to get holds of auction %v: %v", auctionID, err)
+	}
+
+	// Allocate the units to the highest bids, and determine the clearing price.
+	allocations, winningPrice, clearingPrice, tie := allocateUnits(auction, reservePrice)
+
+	winnerOrg := ""
+	if len(allocations) == 0 {
+		// No revealed bid meets the reserve, so the auction ends without a winner.
+		auction.Outcome = reserveNotMetOutcome
+
+		// Any bid that has yet to be revealed and meets the reserve would win.
+		err = checkForHigherBid(ctx, reservePrice-1, reservePrice-1, "", auction.RevealedBids, auction.PrivateBids, holds, auction.RevealDeadline)
+		if err != nil {
+			return fmt.Errorf("Failed to check for higher bid, cannot end auction: %v", err)
+		}
+	} else {
+		auction.Allocations = allocations
+		auction.WinningBid = revealedBids[allocations[0].BidKey].Price
+		auction.Price = clearingPrice
+		auction.Outcome = soldOutcome
+		auction.Tie = tie
+
+		// The item goes to the winner when a single bid wins every unit.
+		if len(allocations) == 1 && allocations[0].Quantity == auction.Quantity {
+			auction.Winner = allocations[0].Bidder
+			winnerOrg = allocations[0].Org
+		}
+
+		// Check if there is a bid that has yet to be revealed and that would change
+		// the winners or the clearing price.
+		marginalBidKey := allocations[len(allocations)-1].BidKey
+		err = checkForHigherBid(ctx, winningPrice, clearingPrice, marginalBidKey, auction.RevealedBids, auction.PrivateBids, holds, auction.RevealDeadline)
+		if err != nil {
+			return fmt.Errorf("Failed to check for higher bid, cannot end auction: %v", err)
 		}
 	}
 
-	// Check if there is a winning bid that has yet to be revealed.
-	err = checkForHigherBid(ctx, auction.Price, auction.RevealedBids, auction.PrivateBids)
+	// Pay the seller and release the other holds.
+	forfeited, err := forfeitedBids(ctx, auction, holds)
 	if err != nil {
-		return fmt.Errorf("Failed to check for higher bid, cannot end auction: %v", err)
+		return err
+	}
+
+	err = settleHolds(ctx, auction, holds, forfeited)
+	if err != nil {
+		return fmt.Errorf("Failed to settle auction payment: %v", err)
+	}
+
+	return endAuction(ctx, auction, winnerOrg)
+}
+
+// endAuction is an internal utility function to end an auction whose winner and
+// price are set. The item is transferred to the winner and their organization,
+// or unlocked if the auction was not sold.
+func endAuction(ctx contractapi.TransactionContextInterface, auction *Auction, winnerOrg string) error {
+	err := releaseItem(ctx, auction, winnerOrg)
+	if err != nil {
+		return fmt.Errorf("Failed to transfer item %v: %v", auction.ItemSold, err)
 	}
 
 	// Change status of auction to ended.
+	Status := auction.Status
 	auction.Status = string("ended")
 
-	// Update the auction in state.
-	endedAuction, _ := json.Marshal(auction)
+	err = updateAuctionStatusIndex(ctx, auction.ID, Status, auction.Status)
+	if err != nil {
+		return fmt.Errorf("Failed to update auction status index: %v", err)
+	}
 
-	err = ctx.GetStub().PutState(auctionID, endedAuction)
+	// Update the auction in state.
+	err = putAuction(ctx, auction.ID, auction)
 	if err != nil {
 		return fmt.Errorf("Failed to end auction: %v", err)
 	}
 
+	// Notify clients that the auction was ended.
+	err = setAuctionEvent(ctx, AuctionEndedEvent, AuctionEvent{
+		AuctionID: auction.ID,
+		Status:    auction.Status,
+		Winner:    auction.Winner,
+		Price:     auction.Price,
+		Outcome:   auction.Outcome,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CancelAuction can be used by the seller to cancel an auction that is open, or
+// that is closed without any revealed bid. A cancelled auction cannot be ended,
+// the tokens held for its bids are returned to the bidders, and its item is
+// unlocked. Once the reveal deadline has passed, the deposits of the bids are
+// paid to the seller.
+func (c *AuctionContract) CancelAuction(ctx contractapi.TransactionContextInterface, auctionID string) error {
+	// Get auction from public state.
+	auction, err := getAuctionWithBids(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("Failed to get auction from public state: %v", err)
+	}
+
+	// The auction can only be cancelled by the seller.
+
+	// Get ID of submitting client identity.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get submitting client identity: %v", err)
+	}
+
+	Seller := auction.Seller
+	if Seller != clientID {
+		return fmt.Errorf("Auction can only be cancelled by seller")
+	}
+
+	// Check that the auction can still be cancelled.
+	Status := auction.Status
+	if Status != "open" && Status != "closed" {
+		return fmt.Errorf("Cannot cancel auction that is not open or closed")
+	}
+	if len(auction.RevealedBids) != 0 {
+		return fmt.Errorf("Cannot cancel auction, bids have already been revealed")
+	}
+
+	// Return the tokens held for the bids.
+	holds, err := getHolds(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("Failed to get holds of auction %v: %v", auctionID, err)
+	}
+
+	forfeited, err := forfeitedBids(ctx, auction, holds)
+	if err != nil {
+		return err
+	}
+
+	err = settleHolds(ctx, auction, holds, forfeited)
+	if err != nil {
+		return fmt.Errorf("Failed to release holds of auction %v: %v", auctionID, err)
+	}
+
+	err = releaseItem(ctx, auction, "")
+	if err != nil {
+		return fmt.Errorf("Failed to unlock item %v: %v", auction.ItemSold, err)
+	}
+
+	// Change status of auction to cancelled.
+	auction.Status = string("cancelled")
+
+	err = updateAuctionStatusIndex(ctx, auctionID, Status, auction.Status)
+	if err != nil {
+		return fmt.Errorf("Failed to update auction status index: %v", err)
+	}
+
+	// Update the auction in state.
+	err = putAuction(ctx, auctionID, auction)
+	if err != nil {
+		return fmt.Errorf("Failed to cancel auction: %v", err)
+	}
+
+	// Notify clients that the auction was cancelled.
+	err = setAuctionEvent(ctx, AuctionCancelledEvent, AuctionEvent{AuctionID: auctionID, Status: auction.Status})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// WithdrawBid can be used by a bidder to withdraw their bid while the auction is
+// open, until the bidding deadline. The bidder passes the bid under the bid key
+// of the transient map, so that the organizations of the auction can check the
+// bid against its hash. The bid is deleted from the private data collection of
+// the bidder's organization, and its hash is removed from the auction, returning
+// the tokens held for it. If the organization of the bidder has no remaining
+// bids, it is removed from the organizations of the auction and from the
+// endorsement policy of the auction.
+func (c *AuctionContract) WithdrawBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string) error {
+	// Get Bid from transient map.
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("Error getting bid from transient map: %v", err)
+	}
+
+	transientBid, ok := transientMap["bid"]
+	if !ok {
+		return fmt.Errorf("Bid key not found in the transient map")
+	}
+
+	// Get ID of submitting client identity.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get submitting client identity: %v", err)
+	}
+
+	// Get the MSP ID of the bidder's org.
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("Failed to get client identity MSP ID: %v", err)
+	}
+
+	// Get auction from public state.
+	auction, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("Failed to get auction from public state: %v", err)
+	}
+
+	// Bids can only be withdrawn while the auction is open.
+	if auction.Status != "open" {
+		return fmt.Errorf("Cannot withdraw bid from closed or ended auction")
+	}
+
+	// Bids can only be withdrawn until the bidding deadline.
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+	if now > auction.BiddingDeadline {
+		return fmt.Errorf("Cannot withdraw bid, bidding deadline %v has passed", auction.BiddingDeadline)
+	}
+
+	// Get the implicit collection name of bidder's org.
+	collection, err := getCollectionName(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get implicit collection name: %v", err)
+	}
+
+	// Create a composite key using the auction ID and transaction ID.
+	bidKey, err := ctx.GetStub().CreateCompositeKey(bidKeyType, []string{auctionID, txID})
+	if err != nil {
+		return fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	// Get the hash of the bid stored in private data collection.
+	bidHash, err := ctx.GetStub().GetPrivateDataHash(collection, bidKey)
+	if err != nil {
+		return fmt.Errorf("Failed to get private bid hash from collection: %v", err)
+	}
+	if bidHash == nil {
+		return fmt.Errorf("Bid hash does not exist in private data collection: %s", bidKey)
+	}
+
+	// Check that the bid passed in the transient map is the stored bid.
+	calculatedBidHash := sha256.Sum256(transientBid)
+	if !bytes.Equal(calculatedBidHash[:], bidHash) {
+		return fmt.Errorf("Hash %x for bid %s does not match hash in collection: %x",
+			calculatedBidHash,
+			transientBid,
+			bidHash,
+		)
+	}
+
+	bid, err := parseTransientBid(transientBid)
+	if err != nil {
+		return fmt.Errorf("Invalid bid: %v", err)
+	}
+
+	// Check that the client withdrawing the bid is the bid owner.
+	if bid.Bidder != clientID {
+		return fmt.Errorf("Permission denied, client id %v is not the owner of the bid", clientID)
+	}
+
+	// Delete the bid from the private data collection.
+	err = ctx.GetStub().DelPrivateData(collection, bidKey)
+	if err != nil {
+		return fmt.Errorf("Failed to delete bid %v from collection: %v", bidKey, err)
+	}
+
+	// Remove the bid hash from the auction, if the bid was submitted.
+	privateBid, err := getPrivateBid(ctx, auctionID, txID)
+	if err != nil {
+		return fmt.Errorf("Failed to get private bid from public state: %v", err)
+	}
+
+	if privateBid != nil {
+		privateBidKey, err := ctx.GetStub().CreateCompositeKey(privateBidKeyType, []string{auctionID, txID})
+		if err != nil {
+			return fmt.Errorf("Failed to create composite key: %v", err)
+		}
+
+		err = ctx.GetStub().DelState(privateBidKey)
+		if err != nil {
+			return fmt.Errorf("Failed to delete private bid %v: %v", privateBidKey, err)
+		}
+
+		// Return the tokens held for the bid.
+		holds, err := getHolds(ctx, auctionID)
+		if err != nil {
+			return fmt.Errorf("Failed to get holds of auction %v: %v", auctionID, err)
+		}
+
+		err = settleHolds(ctx, auction, map[string]Hold{bidKey: holds[bidKey]}, nil)
+		if err != nil {
+			return fmt.Errorf("Failed to release hold of bid %v: %v", bidKey, err)
+		}
+
+		// Remove the organization of the bidder from the auction if it has no
+		// remaining bids. The organization of the seller is always kept.
+		privateBids, err := getPrivateBids(ctx, auctionID)
+		if err != nil {
+			return fmt.Errorf("Failed to get private bids of auction %v: %v", auctionID, err)
+		}
+
+		remainingBids := 0
+		for key, bidHash := range privateBids {
+			if key != bidKey && bidHash.Org == clientOrgID {
+				remainingBids++
+			}
+		}
+
+		if remainingBids == 0 && clientOrgID != auction.Orgs[0] {
+			orgs := []string{}
+			for _, org := range auction.Orgs {
+				if org != clientOrgID {
+					orgs = append(orgs, org)
+				}
+			}
+			auction.Orgs = orgs
+
+			err = setAssetStateBasedEndorsement(ctx, auctionID, auction.Orgs...)
+			if err != nil {
+				return fmt.Errorf("Failed setting state based endorsement for remaining organizations: %v", err)
+			}
+
+			err = putAuction(ctx, auctionID, auction)
+			if err != nil {
+				return fmt.Errorf("Failed to update auction state: %v", err)
+			}
+		}
+	}
+
+	// Notify clients that a bid was withdrawn.
+	err = setAuctionEvent(ctx, BidWithdrawnEvent, AuctionEvent{AuctionID: auctionID, Status: auction.Status, BidKey: bidKey})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -1,9 +1,17 @@
 package contract
 
 import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"auction-chaincode/fakeledger"
+
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 const getStateError = "world state get error"
@@ -41,3 +49,1419 @@ func (mc *MockContext) GetStub() shim.ChaincodeStubInterface {
 
 	return args.Get(0).(*MockStub)
 }
+
+var (
+	seller  = fakeledger.NewIdentity("Org1MSP", "seller")
+	bidder1 = fakeledger.NewIdentity("Org1MSP", "bidder1")
+	bidder2 = fakeledger.NewIdentity("Org2MSP", "bidder2")
+	bidder3 = fakeledger.NewIdentity("Org3MSP", "bidder3")
+	minter  = fakeledger.NewIdentity("Org1MSP", "minter")
+)
+
+// Every bidder is funded with initialBalance tokens, and holds maxBidPrice
+// tokens and the deposit of the auction for each bid.
+const (
+	initialBalance = 1000
+	maxBidPrice    = 400
+	deposit        = 50
+)
+
+// auctionTest runs the transactions of an auction on a fake ledger.
+type auctionTest struct {
+	t        *testing.T
+	ledger   *fakeledger.Ledger
+	contract *AuctionContract
+	token    *TokenContract
+	bids     map[string][]byte
+	events   []*fakeledger.Event
+}
+
+func newAuctionTest(t *testing.T) *auctionTest {
+	at := &auctionTest{
+		t:        t,
+		ledger:   fakeledger.New(),
+		contract: new(AuctionContract),
+		token:    new(TokenContract),
+		bids:     make(map[string][]byte),
+	}
+
+	for _, bidder := range []*fakeledger.Identity{bidder1, bidder2, bidder3} {
+		at.fund(bidder, initialBalance)
+	}
+
+	err := at.submit(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.RegisterItem(ctx, "painting", "Oil on canvas")
+	})
+	require.NoError(t, err)
+
+	return at
+}
+
+func (at *auctionTest) item(itemID string) *Item {
+	var item *Item
+	_, err := at.ledger.Execute(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		item, err = at.contract.ReadItem(ctx, itemID)
+		return err
+	})
+	require.NoError(at.t, err)
+
+	return item
+}
+
+// fund mints tokens and transfers them to a client.
+func (at *auctionTest) fund(client *fakeledger.Identity, amount int) {
+	err := at.submit(fakeledger.Transaction{Client: minter}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.token.Mint(ctx, amount)
+	})
+	require.NoError(at.t, err)
+
+	err = at.submit(fakeledger.Transaction{Client: minter}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.token.Transfer(ctx, client.ID(), amount)
+	})
+	require.NoError(at.t, err)
+}
+
+func (at *auctionTest) balance(client *fakeledger.Identity) int {
+	var balance int
+	_, err := at.ledger.Execute(fakeledger.Transaction{Client: client}, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		balance, err = at.token.ClientAccountBalance(ctx)
+		return err
+	})
+	require.NoError(at.t, err)
+
+	return balance
+}
+
+// submit runs and commits a transaction, and records its chaincode event.
+func (at *auctionTest) submit(tx fakeledger.Transaction, fn func(ctx contractapi.TransactionContextInterface) error) error {
+	ctx, err := at.ledger.Execute(tx, fn)
+	if err != nil {
+		return err
+	}
+
+	err = at.ledger.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	if event := ctx.Stub().Event(); event != nil {
+		at.events = append(at.events, event)
+	}
+
+	return nil
+}
+
+// requireEvent checks the name and payload of the event of the last transaction
+// that set one.
+func (at *auctionTest) requireEvent(name string, expected AuctionEvent) {
+	require.NotEmpty(at.t, at.events)
+	event := at.events[len(at.events)-1]
+	require.Equal(at.t, name, event.Name)
+
+	var payload AuctionEvent
+	require.NoError(at.t, json.Unmarshal(event.Payload, &payload))
+
+	expected.Version = AuctionEventVersion
+	expected.Name = name
+	require.Equal(at.t, expected, payload)
+}
+
+// bidKey returns the key of a bid of an auction.
+func bidKey(auctionID string, txID string) string {
+	return "\x00" + bidKeyType + "\x00" + auctionID + "\x00" + txID + "\x00"
+}
+
+func (at *auctionTest) createAuction(auctionID string, format string, reserve int) {
+	require.NoError(at.t, at.createAuctionOf(seller, auctionID, "painting", format, reserve))
+}
+
+func (at *auctionTest) createAuctionOf(client *fakeledger.Identity, auctionID string, itemID string, format string, reserve int) error {
+	return at.createAuctionOfQuantity(client, auctionID, itemID, format, reserve, 1)
+}
+
+func (at *auctionTest) createAuctionOfQuantity(client *fakeledger.Identity, auctionID string, itemID string, format string, reserve int, quantity int) error {
+	now := at.ledger.Clock.Unix()
+	tx := fakeledger.Transaction{Client: client}
+	if reserve > 0 {
+		tx.Transient = map[string][]byte{"reserve": at.reserve(reserve)}
+	}
+
+	return at.submit(tx, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CreateAuction(ctx, auctionID, itemID, format, now+3600, now+7200, deposit, quantity, nil)
+	})
+}
+
+func (at *auctionTest) reserve(price int) []byte {
+	reserve, err := json.Marshal(Reserve{Type: reserveKeyType, Price: price, Seller: seller.ID(), Salt: strings.Repeat("fedcba9876543210", 4)})
+	require.NoError(at.t, err)
+
+	return reserve
+}
+
+// bid creates and submits a bid, and returns the transaction ID of the bid.
+func (at *auctionTest) bid(auctionID string, bidder *fakeledger.Identity, price int) string {
+	txID, err := at.bidWithHold(auctionID, bidder, price, maxBidPrice)
+	require.NoError(at.t, err)
+
+	return txID
+}
+
+// bidWithHold creates a bid for one unit and submits it with the given maximum
+// price.
+func (at *auctionTest) bidWithHold(auctionID string, bidder *fakeledger.Identity, price int, maxPrice int) (string, error) {
+	return at.bidUnitsWithHold(auctionID, bidder, price, 1, maxPrice)
+}
+
+// bidUnitsWithHold creates a bid for a quantity of units at a unit price, and
+// submits it with the given maximum price.
+func (at *auctionTest) bidUnitsWithHold(auctionID string, bidder *fakeledger.Identity, price int, quantity int, maxPrice int) (string, error) {
+	return at.orderWithHold(auctionID, bidder, "", price, quantity, maxPrice)
+}
+
+// orderWithHold creates an order of a double auction on the given side, and
+// submits it with the given maximum price.
+func (at *auctionTest) orderWithHold(auctionID string, bidder *fakeledger.Identity, side string, price int, quantity int, maxPrice int) (string, error) {
+	bid, err := json.Marshal(FullBid{
+		Type:     bidKeyType,
+		Price:    price,
+		Quantity: quantity,
+		Side:     side,
+		Org:      bidder.MSPID,
+		Bidder:   bidder.ID(),
+		Salt:     strings.Repeat("0123456789abcdef", 4),
+	})
+	require.NoError(at.t, err)
+
+	var txID string
+	err = at.submit(fakeledger.Transaction{Client: bidder, Transient: map[string][]byte{"bid": bid}}, func(ctx contractapi.TransactionContextInterface) error {
+		txID, err = at.contract.CreateBid(ctx, auctionID)
+		return err
+	})
+	require.NoError(at.t, err)
+
+	at.bids[txID] = bid
+
+	err = at.submit(fakeledger.Transaction{Client: bidder}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.SubmitBid(ctx, auctionID, txID, maxPrice)
+	})
+
+	return txID, err
+}
+
+func (at *auctionTest) reveal(auctionID string, bidder *fakeledger.Identity, txID string) error {
+	return at.submit(fakeledger.Transaction{Client: bidder, Transient: map[string][]byte{"bid": at.bids[txID]}}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.RevealBid(ctx, auctionID, txID)
+	})
+}
+
+func (at *auctionTest) close(auctionID string) {
+	err := at.submit(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CloseAuction(ctx, auctionID)
+	})
+	require.NoError(at.t, err)
+}
+
+func (at *auctionTest) end(auctionID string, reserve int) error {
+	tx := fakeledger.Transaction{Client: seller}
+	if reserve > 0 {
+		tx.Transient = map[string][]byte{"reserve": at.reserve(reserve)}
+	}
+
+	return at.submit(tx, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.EndAuction(ctx, auctionID)
+	})
+}
+
+func (at *auctionTest) query(auctionID string) *Auction {
+	var auction *Auction
+	_, err := at.ledger.Execute(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		auction, err = at.contract.QueryAuction(ctx, auctionID)
+		return err
+	})
+	require.NoError(at.t, err)
+
+	return auction
+}
+
+func TestBlindAuction(t *testing.T) {
+	tests := []struct {
+		format  string
+		reserve int
+		winner  *fakeledger.Identity
+		price   int
+		outcome string
+	}{
+		{format: firstPriceFormat, winner: bidder2, price: 300, outcome: soldOutcome},
+		{format: secondPriceFormat, winner: bidder2, price: 200, outcome: soldOutcome},
+		{format: secondPriceFormat, reserve: 250, winner: bidder2, price: 250, outcome: soldOutcome},
+		{format: firstPriceFormat, reserve: 400, price: 0, outcome: reserveNotMetOutcome},
+	}
+
+	for _, test := range tests {
+		at := newAuctionTest(t)
+		at.createAuction("auction1", test.format, test.reserve)
+		at.requireEvent(AuctionCreatedEvent, AuctionEvent{AuctionID: "auction1", Status: "open"})
+
+		bid1 := at.bid("auction1", bidder1, 100)
+		bid2 := at.bid("auction1", bidder2, 300)
+		bid3 := at.bid("auction1", bidder3, 200)
+		at.requireEvent(BidSubmittedEvent, AuctionEvent{AuctionID: "auction1", Status: "open", BidKey: bidKey("auction1", bid3)})
+
+		auction := at.query("auction1")
+		require.Equal(t, []string{"Org1MSP", "Org2MSP", "Org3MSP"}, auction.Orgs)
+		require.Len(t, auction.PrivateBids, 3)
+
+		require.Equal(t, initialBalance-maxBidPrice-deposit, at.balance(bidder1))
+
+		at.ledger.Advance(time.Hour)
+		at.close("auction1")
+		at.requireEvent(AuctionClosedEvent, AuctionEvent{AuctionID: "auction1", Status: "closed"})
+
+		require.NoError(t, at.reveal("auction1", bidder1, bid1))
+		require.NoError(t, at.reveal("auction1", bidder2, bid2))
+		require.NoError(t, at.reveal("auction1", bidder3, bid3))
+		at.requireEvent(BidRevealedEvent, AuctionEvent{AuctionID: "auction1", Status: "closed", BidKey: bidKey("auction1", bid3)})
+
+		require.NoError(t, at.end("auction1", test.reserve))
+
+		// The event of the end of the auction carries the outcome, but no bid
+		// price other than the price paid.
+		ended := AuctionEvent{AuctionID: "auction1", Status: "ended", Price: test.price, Outcome: test.outcome}
+		if test.winner != nil {
+			ended.Winner = test.winner.ID()
+		}
+		at.requireEvent(AuctionEndedEvent, ended)
+
+		// The winner pays the price to the seller, every other hold is released.
+		require.Equal(t, test.price, at.balance(seller))
+		require.Equal(t, initialBalance, at.balance(bidder1))
+		require.Equal(t, initialBalance-test.price, at.balance(bidder2))
+		require.Equal(t, initialBalance, at.balance(bidder3))
+
+		// The item is transferred to the winner, or unlocked without a winner.
+		item := at.item("painting")
+		require.Empty(t, item.AuctionID)
+		if test.winner != nil {
+			require.Equal(t, test.winner.ID(), item.Owner)
+			require.Equal(t, test.winner.MSPID, item.OwnerOrg)
+		} else {
+			require.Equal(t, seller.ID(), item.Owner)
+		}
+
+		auction = at.query("auction1")
+		require.Equal(t, "ended", auction.Status)
+		require.Equal(t, test.price, auction.Price)
+		require.Equal(t, test.outcome, auction.Outcome)
+		require.False(t, auction.Tie)
+		if test.winner != nil {
+			require.Equal(t, test.winner.ID(), auction.Winner)
+		} else {
+			require.Empty(t, auction.Winner)
+		}
+	}
+}
+
+func TestMultiUnitAuction(t *testing.T) {
+	tests := []struct {
+		format   string
+		quantity int
+		price    int
+		units    map[*fakeledger.Identity]int
+	}{
+		{format: firstPriceFormat, quantity: 4, price: 200, units: map[*fakeledger.Identity]int{bidder2: 2, bidder3: 2}},
+		{format: secondPriceFormat, quantity: 4, price: 100, units: map[*fakeledger.Identity]int{bidder2: 2, bidder3: 2}},
+		{format: firstPriceFormat, quantity: 5, price: 100, units: map[*fakeledger.Identity]int{bidder2: 2, bidder3: 2, bidder1: 1}},
+	}
+
+	for _, test := range tests {
+		at := newAuctionTest(t)
+		require.NoError(t, at.createAuctionOfQuantity(seller, "auction1", "painting", test.format, 0, test.quantity))
+
+		bids := map[*fakeledger.Identity]string{}
+		for bidder, price := range map[*fakeledger.Identity]int{bidder1: 100, bidder2: 300, bidder3: 200} {
+			txID, err := at.bidUnitsWithHold("auction1", bidder, price, 2, 2*maxBidPrice)
+			require.NoError(t, err)
+			bids[bidder] = txID
+		}
+
+		at.ledger.Advance(time.Hour)
+		at.close("auction1")
+
+		for bidder, txID := range bids {
+			require.NoError(t, at.reveal("auction1", bidder, txID))
+		}
+		require.NoError(t, at.end("auction1", 0))
+
+		// Every winner pays the same unit price for the units allocated to it.
+		auction := at.query("auction1")
+		require.Equal(t, soldOutcome, auction.Outcome)
+		require.Equal(t, test.price, auction.Price)
+		require.Len(t, auction.Allocations, len(test.units))
+		for _, allocation := range auction.Allocations {
+			require.Equal(t, test.price, allocation.Price)
+		}
+
+		sold := 0
+		for _, bidder := range []*fakeledger.Identity{bidder1, bidder2, bidder3} {
+			require.Equal(t, initialBalance-test.units[bidder]*test.price, at.balance(bidder))
+			sold += test.units[bidder]
+		}
+		require.Equal(t, sold*test.price, at.balance(seller))
+
+		// The units are sold to several bidders, so the item stays with the seller.
+		require.Empty(t, auction.Winner)
+		require.Equal(t, seller.ID(), at.item("painting").Owner)
+	}
+}
+
+func TestDoubleAuction(t *testing.T) {
+	at := newAuctionTest(t)
+
+	now := at.ledger.Clock.Unix()
+
+	// The sell orders only hold the deposit, which has to stake tokens.
+	err := at.submit(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CreateDoubleAuction(ctx, "auction1", "apples", now+3600, now+7200, 0)
+	})
+	require.Error(t, err)
+
+	err = at.submit(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CreateDoubleAuction(ctx, "auction1", "apples", now+3600, now+7200, deposit)
+	})
+	require.NoError(t, err)
+
+	ask1, err := at.orderWithHold("auction1", bidder1, askSide, 10, 3, 0)
+	require.NoError(t, err)
+	ask2, err := at.orderWithHold("auction1", bidder3, askSide, 20, 2, 0)
+	require.NoError(t, err)
+	bid, err := at.orderWithHold("auction1", bidder2, bidSide, 30, 4, 120)
+	require.NoError(t, err)
+
+	at.ledger.Advance(time.Hour)
+	at.close("auction1")
+
+	require.NoError(t, at.reveal("auction1", bidder1, ask1))
+	require.NoError(t, at.reveal("auction1", bidder2, bid))
+
+	clearAuction := func(peerMSPID string) error {
+		return at.submit(fakeledger.Transaction{Client: seller, PeerMSPID: peerMSPID}, func(ctx contractapi.TransactionContextInterface) error {
+			return at.contract.ClearAuction(ctx, "auction1")
+		})
+	}
+
+	// The unrevealed ask would trade with the units left of the bid.
+	err = clearAuction("Org3MSP")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "ask would change the clearing")
+
+	require.NoError(t, at.reveal("auction1", bidder3, ask2))
+	require.NoError(t, clearAuction(""))
+
+	// The bid buys 3 units of the first ask and 1 unit of the second, half way
+	// between the last bid and ask matched.
+	auction := at.query("auction1")
+	require.Equal(t, "ended", auction.Status)
+	require.Equal(t, soldOutcome, auction.Outcome)
+	require.Equal(t, 25, auction.Price)
+	require.Len(t, auction.Matches, 2)
+	require.Equal(t, bidder1.ID(), auction.Matches[0].Seller)
+	require.Equal(t, 3, auction.Matches[0].Quantity)
+	require.Equal(t, bidder3.ID(), auction.Matches[1].Seller)
+	require.Equal(t, 1, auction.Matches[1].Quantity)
+
+	require.Equal(t, initialBalance+75, at.balance(bidder1))
+	require.Equal(t, initialBalance-100, at.balance(bidder2))
+	require.Equal(t, initialBalance+25, at.balance(bidder3))
+	require.Equal(t, 0, at.balance(seller))
+
+	// The orders name their quantities, the auction has none.
+	auctionJSON, err := json.Marshal(auction)
+	require.NoError(t, err)
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(auctionJSON, &fields))
+	require.NotContains(t, fields, "quantity")
+}
+
+func TestEndAuctionBreaksTiesByEarliestSubmission(t *testing.T) {
+	// Either bidder wins if it submits first, whatever the keys of the bids.
+	for _, bidders := range [][]*fakeledger.Identity{{bidder1, bidder3}, {bidder3, bidder1}} {
+		at := newAuctionTest(t)
+		at.createAuction("auction1", firstPriceFormat, 0)
+
+		first := at.bid("auction1", bidders[0], 300)
+		at.ledger.Advance(time.Minute)
+		second := at.bid("auction1", bidders[1], 300)
+		other := at.bid("auction1", bidder2, 200)
+
+		at.ledger.Advance(time.Hour)
+		at.close("auction1")
+
+		require.NoError(t, at.reveal("auction1", bidders[1], second))
+		require.NoError(t, at.reveal("auction1", bidders[0], first))
+		require.NoError(t, at.reveal("auction1", bidder2, other))
+		require.NoError(t, at.end("auction1", 0))
+
+		auction := at.query("auction1")
+		require.Equal(t, tieBreakRule, auction.TieBreak)
+		require.True(t, auction.Tie)
+		require.Equal(t, bidders[0].ID(), auction.Winner)
+		require.Equal(t, 300, auction.Price)
+	}
+}
+
+func TestEndAuctionRequiresEarlierTiedBidsToBeRevealed(t *testing.T) {
+	at := newAuctionTest(t)
+	at.createAuction("auction1", firstPriceFormat, 0)
+
+	first := at.bid("auction1", bidder2, 300)
+	at.ledger.Advance(time.Minute)
+	second := at.bid("auction1", bidder1, 300)
+
+	at.ledger.Advance(time.Hour)
+	at.close("auction1")
+	require.NoError(t, at.reveal("auction1", bidder1, second))
+
+	// The unrevealed bid has the same price and was submitted first, so it would
+	// win the tie-break.
+	err := at.submit(fakeledger.Transaction{Client: seller, PeerMSPID: "Org2MSP"}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.EndAuction(ctx, "auction1")
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bidder has the same price and was submitted first")
+
+	require.NoError(t, at.reveal("auction1", bidder2, first))
+	require.NoError(t, at.end("auction1", 0))
+	require.Equal(t, bidder2.ID(), at.query("auction1").Winner)
+}
+
+func TestEndAuctionRequiresHigherBidsToBeRevealed(t *testing.T) {
+	at := newAuctionTest(t)
+	at.createAuction("auction1", firstPriceFormat, 0)
+
+	bid1 := at.bid("auction1", bidder1, 100)
+	at.bid("auction1", bidder2, 300)
+
+	at.ledger.Advance(time.Hour)
+	at.close("auction1")
+	require.NoError(t, at.reveal("auction1", bidder1, bid1))
+
+	// Only the peers of the organization of the unrevealed bid can read it, and
+	// the auction cannot be ended without their endorsement.
+	err := at.submit(fakeledger.Transaction{Client: seller, PeerMSPID: "Org2MSP"}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.EndAuction(ctx, "auction1")
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bidder has a higher price")
+}
+
+func TestEndAuctionForfeitsDepositsOfUnrevealedBids(t *testing.T) {
+	at := newAuctionTest(t)
+	at.createAuction("auction1", firstPriceFormat, 0)
+
+	bid1 := at.bid("auction1", bidder1, 100)
+	bid2 := at.bid("auction1", bidder2, 300)
+	bid3 := at.bid("auction1", bidder3, 200)
+
+	at.ledger.Advance(time.Hour)
+	at.close("auction1")
+	require.NoError(t, at.reveal("auction1", bidder1, bid1))
+	require.NoError(t, at.reveal("auction1", bidder3, bid3))
+
+	// Once the reveal deadline has passed, the unrevealed bid no longer blocks
+	// the auction, even on the peers that can read it.
+	at.ledger.Advance(time.Hour + time.Second)
+
+	err := at.reveal("auction1", bidder2, bid2)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "reveal deadline")
+
+	err = at.submit(fakeledger.Transaction{Client: bidder1, PeerMSPID: "Org2MSP"}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.EndAuction(ctx, "auction1")
+	})
+	require.NoError(t, err)
+
+	auction := at.query("auction1")
+	require.Equal(t, bidder3.ID(), auction.Winner)
+	require.Equal(t, 200, auction.Price)
+
+	// The deposit of the unrevealed bid is paid to the seller, revealed bids get
+	// their deposits back.
+	require.Equal(t, 200+deposit, at.balance(seller))
+	require.Equal(t, initialBalance, at.balance(bidder1))
+	require.Equal(t, initialBalance-deposit, at.balance(bidder2))
+	require.Equal(t, initialBalance-200, at.balance(bidder3))
+}
+
+func TestRevealBidRejectsChangedBid(t *testing.T) {
+	at := newAuctionTest(t)
+	at.createAuction("auction1", firstPriceFormat, 0)
+
+	bid1 := at.bid("auction1", bidder1, 100)
+	at.bids[bid1] = []byte(strings.Replace(string(at.bids[bid1]), "100", "900", 1))
+
+	at.ledger.Advance(time.Hour)
+	at.close("auction1")
+
+	err := at.reveal("auction1", bidder1, bid1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not match hash")
+}
+
+func TestCreateBidRejectsInvalidBids(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(bid map[string]interface{})
+		closed bool
+		err    string
+	}{
+		{name: "missing salt", modify: func(bid map[string]interface{}) { delete(bid, "salt") }, err: "Salt is missing"},
+		{name: "non-hex salt", modify: func(bid map[string]interface{}) { bid["salt"] = strings.Repeat("zz", 32) }, err: "Salt must be hex encoded"},
+		{name: "short salt", modify: func(bid map[string]interface{}) { bid["salt"] = "0123456789abcdef" }, err: "Salt must contain at least 16 bytes, got 8"},
+		{name: "repeated byte salt", modify: func(bid map[string]interface{}) { bid["salt"] = strings.Repeat("ab", 32) }, err: "Salt must be random"},
+		{name: "unknown field", modify: func(bid map[string]interface{}) { bid["reserve"] = 100 }, err: "unknown field"},
+		{name: "wrong object type", modify: func(bid map[string]interface{}) { bid["objectType"] = reserveKeyType }, err: "Bid object type must be bid"},
+		{name: "zero price", modify: func(bid map[string]interface{}) { bid["price"] = 0 }, err: "Bid price must be positive"},
+		{name: "negative price", modify: func(bid map[string]interface{}) { bid["price"] = -100 }, err: "Bid price must be positive"},
+		{name: "wrong org", modify: func(bid map[string]interface{}) { bid["org"] = bidder2.MSPID }, err: "does not match client org"},
+		{name: "wrong bidder", modify: func(bid map[string]interface{}) { bid["bidder"] = bidder2.ID() }, err: "is not the owner of the bid"},
+		{name: "closed auction", modify: func(bid map[string]interface{}) {}, closed: true, err: "Cannot bid on closed or ended auction"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			at := newAuctionTest(t)
+			at.createAuction("auction1", firstPriceFormat, 0)
+			if test.closed {
+				at.ledger.Advance(time.Hour)
+				at.close("auction1")
+			}
+
+			bid := map[string]interface{}{
+				"objectType": bidKeyType,
+				"price":      100,
+				"quantity":   1,
+				"org":        bidder1.MSPID,
+				"bidder":     bidder1.ID(),
+				"salt":       strings.Repeat("0123456789abcdef", 4),
+			}
+			test.modify(bid)
+
+			transientBid, err := json.Marshal(bid)
+			require.NoError(t, err)
+
+			err = at.submit(fakeledger.Transaction{Client: bidder1, Transient: map[string][]byte{"bid": transientBid}}, func(ctx contractapi.TransactionContextInterface) error {
+				_, err := at.contract.CreateBid(ctx, "auction1")
+				return err
+			})
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.err)
+		})
+	}
+}
+
+func TestCreateBidRequiresPeerOfBidderOrg(t *testing.T) {
+	at := newAuctionTest(t)
+	at.createAuction("auction1", firstPriceFormat, 0)
+
+	bid, err := json.Marshal(FullBid{
+		Type:     bidKeyType,
+		Price:    100,
+		Quantity: 1,
+		Org:      bidder2.MSPID,
+		Bidder:   bidder2.ID(),
+		Salt:     strings.Repeat("0123456789abcdef", 4),
+	})
+	require.NoError(t, err)
+
+	tx := fakeledger.Transaction{Client: bidder2, PeerMSPID: "Org1MSP", Transient: map[string][]byte{"bid": bid}}
+	err = at.submit(tx, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := at.contract.CreateBid(ctx, "auction1")
+		return err
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not a member of this org")
+}
+
+func TestEndAuctionRequiresEndorsementOfEveryBiddingOrg(t *testing.T) {
+	at := newAuctionTest(t)
+	at.createAuction("auction1", secondPriceFormat, 0)
+
+	bid1 := at.bid("auction1", bidder1, 100)
+	bid2 := at.bid("auction1", bidder2, 300)
+	bid3 := at.bid("auction1", bidder3, 200)
+
+	peers := []string{"Org1MSP", "Org2MSP", "Org3MSP"}
+	endAuction := func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.EndAuction(ctx, "auction1")
+	}
+
+	at.ledger.Advance(time.Hour)
+	err := at.ledger.SubmitEndorsed(fakeledger.Transaction{Client: seller}, peers, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CloseAuction(ctx, "auction1")
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, at.reveal("auction1", bidder1, bid1))
+	require.NoError(t, at.reveal("auction1", bidder3, bid3))
+
+	// The seller colludes with the organizations that have no unrevealed bid to
+	// end the auction early. Both peers endorse, but the auction can only be
+	// updated with the endorsement of every organization that submitted a bid.
+	proposal := at.ledger.Endorse(fakeledger.Transaction{Client: seller}, []string{"Org1MSP", "Org3MSP"}, endAuction)
+	require.Equal(t, []string{"Org1MSP", "Org3MSP"}, proposal.Endorsers())
+
+	err = at.ledger.CommitProposal(proposal)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "state validation parameter not satisfied by [Org1MSP Org3MSP]")
+
+	// The peer of the organization with the higher unrevealed bid refuses to
+	// endorse, so asking every organization fails as well.
+	proposal = at.ledger.Endorse(fakeledger.Transaction{Client: seller}, peers, endAuction)
+	require.Equal(t, []string{"Org1MSP", "Org3MSP"}, proposal.Endorsers())
+	require.Contains(t, proposal.Endorsements[1].Err.Error(), "bidder has a higher price")
+	require.Error(t, at.ledger.CommitProposal(proposal))
+	require.Equal(t, "closed", at.query("auction1").Status)
+
+	// Once every bid is revealed, all organizations endorse the end of the auction.
+	require.NoError(t, at.reveal("auction1", bidder2, bid2))
+
+	err = at.ledger.SubmitEndorsed(fakeledger.Transaction{Client: seller}, peers, endAuction)
+	require.NoError(t, err)
+
+	auction := at.query("auction1")
+	require.Equal(t, "ended", auction.Status)
+	require.Equal(t, bidder2.ID(), auction.Winner)
+	require.Equal(t, 200, auction.Price)
+}
+
+func TestRevealBidRequiresEndorsementOfSellerAndBidderOrgs(t *testing.T) {
+	at := newAuctionTest(t)
+	at.createAuction("auction1", firstPriceFormat, 0)
+
+	at.bid("auction1", bidder1, 100)
+	bid2 := at.bid("auction1", bidder2, 300)
+	at.bid("auction1", bidder3, 200)
+
+	at.ledger.Advance(time.Hour)
+	at.close("auction1")
+
+	// Any single organization satisfies the chaincode policy of new keys.
+	at.ledger.ChaincodePolicy = fakeledger.AnyOf("Org1MSP", "Org2MSP", "Org3MSP")
+
+	revealBid := func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.RevealBid(ctx, "auction1", bid2)
+	}
+	tx := fakeledger.Transaction{Client: bidder2, Transient: map[string][]byte{"bid": at.bids[bid2]}}
+
+	// The organization of the bidder alone cannot add the revealed bid.
+	err := at.ledger.SubmitEndorsed(tx, []string{"Org2MSP"}, revealBid)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "state validation parameter not satisfied by [Org2MSP]")
+	require.Empty(t, at.query("auction1").RevealedBids)
+
+	// The organization of the seller checks the bid along with it.
+	err = at.ledger.SubmitEndorsed(tx, []string{"Org1MSP", "Org2MSP"}, revealBid)
+	require.NoError(t, err)
+	require.Len(t, at.query("auction1").RevealedBids, 1)
+}
+
+func TestSubmitBidOfNewOrgDoesNotConflictWithOtherBids(t *testing.T) {
+	at := newAuctionTest(t)
+	at.createAuction("auction1", firstPriceFormat, 0)
+	at.bid("auction1", bidder1, 100)
+
+	bid, err := json.Marshal(FullBid{
+		Type:     bidKeyType,
+		Price:    200,
+		Quantity: 1,
+		Org:      bidder3.MSPID,
+		Bidder:   bidder3.ID(),
+		Salt:     strings.Repeat("0123456789abcdef", 4),
+	})
+	require.NoError(t, err)
+
+	var txID string
+	err = at.submit(fakeledger.Transaction{Client: bidder3, Transient: map[string][]byte{"bid": bid}}, func(ctx contractapi.TransactionContextInterface) error {
+		txID, err = at.contract.CreateBid(ctx, "auction1")
+		return err
+	})
+	require.NoError(t, err)
+
+	// The first bid of an organization adds it to the auction, while the bids of
+	// an organization already in the auction are committed before it.
+	submitBid, err := at.ledger.Execute(fakeledger.Transaction{Client: bidder3}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.SubmitBid(ctx, "auction1", txID, maxBidPrice)
+	})
+	require.NoError(t, err)
+
+	at.bid("auction1", bidder1, 150)
+
+	require.NoError(t, at.ledger.Commit(submitBid))
+
+	auction := at.query("auction1")
+	require.Equal(t, []string{"Org1MSP", "Org3MSP"}, auction.Orgs)
+	require.Len(t, auction.PrivateBids, 3)
+}
+
+func TestListAuctionsPages(t *testing.T) {
+	at := newAuctionTest(t)
+
+	for _, itemID := range []string{"statue", "vase"} {
+		err := at.submit(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+			return at.contract.RegisterItem(ctx, itemID, "Sold by the seller")
+		})
+		require.NoError(t, err)
+	}
+	err := at.submit(fakeledger.Transaction{Client: bidder2}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.RegisterItem(ctx, "sculpture", "Sold by another seller")
+	})
+	require.NoError(t, err)
+
+	at.createAuction("auction1", firstPriceFormat, 0)
+	require.NoError(t, at.createAuctionOf(seller, "auction2", "statue", firstPriceFormat, 0))
+	require.NoError(t, at.createAuctionOf(seller, "auction3", "vase", secondPriceFormat, 0))
+	require.NoError(t, at.createAuctionOf(bidder2, "auction4", "sculpture", firstPriceFormat, 0))
+
+	// Every auction of a page is returned with its bids.
+	at.bid("auction2", bidder1, 100)
+
+	at.ledger.Advance(time.Hour)
+	at.close("auction1")
+
+	type list func(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*AuctionQueryResult, error)
+
+	// pages follows the bookmarks from the first page to the last, and returns
+	// the IDs of the auctions of each page.
+	pages := func(fn list, pageSize int32) [][]string {
+		result := [][]string{}
+		bookmark := ""
+		for {
+			var page *AuctionQueryResult
+			_, err := at.ledger.Execute(fakeledger.Transaction{Client: bidder3}, func(ctx contractapi.TransactionContextInterface) error {
+				var err error
+				page, err = fn(ctx, pageSize, bookmark)
+				return err
+			})
+			require.NoError(t, err)
+			require.Equal(t, int32(len(page.Auctions)), page.FetchedRecordsCount)
+
+			ids := []string{}
+			for _, auction := range page.Auctions {
+				ids = append(ids, auction.ID)
+				if auction.ID == "auction2" {
+					require.Len(t, auction.PrivateBids, 1)
+				}
+			}
+			result = append(result, ids)
+
+			if page.Bookmark == "" {
+				return result
+			}
+			bookmark = page.Bookmark
+		}
+	}
+
+	byStatus := func(status string) list {
+		return func(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*AuctionQueryResult, error) {
+			return at.contract.ListAuctionsByStatus(ctx, status, pageSize, bookmark)
+		}
+	}
+	bySeller := func(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*AuctionQueryResult, error) {
+		return at.contract.ListAuctionsBySeller(ctx, seller.ID(), pageSize, bookmark)
+	}
+	byItem := func(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*AuctionQueryResult, error) {
+		return at.contract.ListAuctionsByItem(ctx, "vase", pageSize, bookmark)
+	}
+	search := func(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*AuctionQueryResult, error) {
+		return at.contract.SearchAuctions(ctx, "open", seller.ID(), "", pageSize, bookmark)
+	}
+
+	require.Equal(t, [][]string{{"auction2", "auction3"}, {"auction4"}}, pages(byStatus("open"), 2))
+	require.Equal(t, [][]string{{"auction1"}}, pages(byStatus("closed"), 2))
+	require.Equal(t, [][]string{{}}, pages(byStatus("ended"), 2))
+	require.Equal(t, [][]string{{"auction1", "auction2"}, {"auction3"}}, pages(bySeller, 2))
+	require.Equal(t, [][]string{{"auction1"}, {"auction2"}, {"auction3"}}, pages(bySeller, 1))
+	require.Equal(t, [][]string{{"auction3"}}, pages(byItem, maxPageSize))
+	require.Equal(t, [][]string{{"auction2", "auction3"}}, pages(search, 2))
+
+	// Pages must hold at least one auction and at most maxPageSize.
+	for _, fn := range []list{byStatus("open"), bySeller, byItem, search} {
+		for _, pageSize := range []int32{0, -1, maxPageSize + 1} {
+			_, err := at.ledger.Execute(fakeledger.Transaction{Client: bidder3}, func(ctx contractapi.TransactionContextInterface) error {
+				_, err := fn(ctx, pageSize, "")
+				return err
+			})
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "Page size must be")
+		}
+	}
+}
+
+func TestTokenTransfer(t *testing.T) {
+	at := newAuctionTest(t)
+
+	err := at.submit(fakeledger.Transaction{Client: bidder2}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.token.Mint(ctx, 100)
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "only clients of Org1MSP can mint")
+
+	err = at.submit(fakeledger.Transaction{Client: bidder1}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.token.Transfer(ctx, bidder2.ID(), 300)
+	})
+	require.NoError(t, err)
+	require.Equal(t, initialBalance-300, at.balance(bidder1))
+	require.Equal(t, initialBalance+300, at.balance(bidder2))
+
+	err = at.submit(fakeledger.Transaction{Client: bidder1}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.token.Transfer(ctx, bidder2.ID(), initialBalance)
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Insufficient funds")
+
+	err = at.submit(fakeledger.Transaction{Client: bidder1}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.token.Transfer(ctx, bidder1.ID(), 100)
+	})
+	require.Error(t, err)
+	require.Equal(t, initialBalance-300, at.balance(bidder1))
+}
+
+func TestSubmitBidHoldsMaximumPrice(t *testing.T) {
+	at := newAuctionTest(t)
+	at.createAuction("auction1", firstPriceFormat, 0)
+
+	// The bidder cannot hold more tokens than they have.
+	_, err := at.bidWithHold("auction1", bidder1, 100, initialBalance+1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Insufficient funds")
+
+	// A bid above the tokens held for it cannot be revealed, and is ignored by
+	// the peers of its organization when the auction ends.
+	bid1 := at.bid("auction1", bidder1, 100)
+	bid2, err := at.bidWithHold("auction1", bidder2, 300, 200)
+	require.NoError(t, err)
+
+	err = at.submit(fakeledger.Transaction{Client: bidder2}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.SubmitBid(ctx, "auction1", bid2, 200)
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "already submitted")
+	require.Equal(t, initialBalance-200-deposit, at.balance(bidder2))
+
+	at.ledger.Advance(time.Hour)
+	at.close("auction1")
+	require.NoError(t, at.reveal("auction1", bidder1, bid1))
+
+	err = at.reveal("auction1", bidder2, bid2)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exceeds the 200 tokens held")
+
+	err = at.submit(fakeledger.Transaction{Client: seller, PeerMSPID: "Org2MSP"}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.EndAuction(ctx, "auction1")
+	})
+	require.NoError(t, err)
+	require.Equal(t, 100, at.balance(seller))
+	require.Equal(t, initialBalance-100, at.balance(bidder1))
+	require.Equal(t, initialBalance, at.balance(bidder2))
+}
+
+func TestEndAuctionWithoutReserveWithheldBySeller(t *testing.T) {
+	at := newAuctionTest(t)
+	at.createAuction("auction1", firstPriceFormat, 100)
+
+	bid1 := at.bid("auction1", bidder1, 200)
+	bid2 := at.bid("auction1", bidder2, 300)
+
+	at.ledger.Advance(time.Hour)
+	at.close("auction1")
+
+	require.NoError(t, at.reveal("auction1", bidder1, bid1))
+	require.NoError(t, at.reveal("auction1", bidder2, bid2))
+
+	// The seller never ends the auction, so a bidder ends it without the reserve.
+	end := func() error {
+		return at.submit(fakeledger.Transaction{Client: bidder2}, func(ctx contractapi.TransactionContextInterface) error {
+			return at.contract.EndAuction(ctx, "auction1")
+		})
+	}
+
+	at.ledger.Advance(2 * time.Hour)
+	require.Error(t, end())
+
+	at.ledger.Advance(reserveRevealPeriod * time.Second)
+	require.NoError(t, end())
+
+	// No bid meets a withheld reserve, so every hold is released.
+	require.Equal(t, 0, at.balance(seller))
+	require.Equal(t, initialBalance, at.balance(bidder1))
+	require.Equal(t, initialBalance, at.balance(bidder2))
+
+	item := at.item("painting")
+	require.Empty(t, item.AuctionID)
+	require.Equal(t, seller.ID(), item.Owner)
+
+	auction := at.query("auction1")
+	require.Equal(t, "ended", auction.Status)
+	require.Equal(t, reserveNotMetOutcome, auction.Outcome)
+	require.Empty(t, auction.Winner)
+}
+
+func TestCancelAuctionReleasesHolds(t *testing.T) {
+	at := newAuctionTest(t)
+	at.createAuction("auction1", firstPriceFormat, 0)
+
+	at.bid("auction1", bidder1, 100)
+	at.bid("auction1", bidder1, 200)
+	at.bid("auction1", bidder2, 300)
+	require.Equal(t, initialBalance-2*(maxBidPrice+deposit), at.balance(bidder1))
+
+	err := at.submit(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CancelAuction(ctx, "auction1")
+	})
+	require.NoError(t, err)
+	at.requireEvent(AuctionCancelledEvent, AuctionEvent{AuctionID: "auction1", Status: "cancelled"})
+	require.Equal(t, initialBalance, at.balance(bidder1))
+	require.Equal(t, initialBalance, at.balance(bidder2))
+
+	// The item can be sold again once the auction is cancelled.
+	require.Empty(t, at.item("painting").AuctionID)
+	require.NoError(t, at.createAuctionOf(seller, "auction2", "painting", firstPriceFormat, 0))
+}
+
+func TestWithdrawBid(t *testing.T) {
+	at := newAuctionTest(t)
+	at.createAuction("auction1", firstPriceFormat, 0)
+
+	bid1 := at.bid("auction1", bidder1, 100)
+	bid2 := at.bid("auction1", bidder2, 300)
+	require.Equal(t, []string{"Org1MSP", "Org2MSP"}, at.query("auction1").Orgs)
+
+	withdraw := func(client *fakeledger.Identity, txID string) error {
+		return at.submit(fakeledger.Transaction{Client: client, Transient: map[string][]byte{"bid": at.bids[txID]}}, func(ctx contractapi.TransactionContextInterface) error {
+			return at.contract.WithdrawBid(ctx, "auction1", txID)
+		})
+	}
+
+	// Only the bidder can withdraw their bid.
+	err := withdraw(seller, bid1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not the owner of the bid")
+
+	// The organization of the bidder leaves the auction with its last bid.
+	require.NoError(t, withdraw(bidder2, bid2))
+	at.requireEvent(BidWithdrawnEvent, AuctionEvent{AuctionID: "auction1", Status: "open", BidKey: bidKey("auction1", bid2)})
+	require.Equal(t, initialBalance, at.balance(bidder2))
+
+	auction := at.query("auction1")
+	require.Equal(t, []string{"Org1MSP"}, auction.Orgs)
+	require.Len(t, auction.PrivateBids, 1)
+
+	// The history of the auction only shows the organization joining and
+	// leaving, the bids themselves are stored under their own keys.
+	var history []*AuctionHistoryEntry
+	_, err = at.ledger.Execute(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		history, err = at.contract.GetAuctionHistory(ctx, "auction1")
+		return err
+	})
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	require.Equal(t, createdStep, history[0].Step)
+	require.Equal(t, orgJoinedStep, history[1].Step)
+	require.Equal(t, orgLeftStep, history[2].Step)
+
+	// Bids cannot be withdrawn once the bidding deadline has passed.
+	at.ledger.Advance(time.Hour + time.Second)
+	err = withdraw(bidder1, bid1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bidding deadline")
+
+	// The auction and the remaining bid no longer need the endorsement of the
+	// organization that left.
+	at.ledger.ChaincodePolicy = fakeledger.AnyOf("Org1MSP", "Org2MSP")
+	org1 := []string{"Org1MSP"}
+
+	err = at.ledger.SubmitEndorsed(fakeledger.Transaction{Client: seller}, org1, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CloseAuction(ctx, "auction1")
+	})
+	require.NoError(t, err)
+
+	err = at.ledger.SubmitEndorsed(fakeledger.Transaction{Client: bidder1, Transient: map[string][]byte{"bid": at.bids[bid1]}}, org1, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.RevealBid(ctx, "auction1", bid1)
+	})
+	require.NoError(t, err)
+
+	err = at.ledger.SubmitEndorsed(fakeledger.Transaction{Client: seller}, org1, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.EndAuction(ctx, "auction1")
+	})
+	require.NoError(t, err)
+	require.Equal(t, bidder1.ID(), at.query("auction1").Winner)
+	require.Equal(t, initialBalance-100, at.balance(bidder1))
+}
+
+func TestCreateAuctionRequiresSaltedReserve(t *testing.T) {
+	at := newAuctionTest(t)
+
+	// Without a salt, the reserve could be guessed from its hash.
+	reserve, err := json.Marshal(Reserve{Type: reserveKeyType, Price: 100, Seller: seller.ID()})
+	require.NoError(t, err)
+
+	now := at.ledger.Clock.Unix()
+	err = at.submit(fakeledger.Transaction{Client: seller, Transient: map[string][]byte{"reserve": reserve}}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CreateAuction(ctx, "auction1", "painting", firstPriceFormat, now+3600, now+7200, deposit, 1, nil)
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Salt is missing")
+}
+
+func TestCreateAuctionRequiresItemOfSeller(t *testing.T) {
+	at := newAuctionTest(t)
+
+	err := at.createAuctionOf(seller, "auction1", "sculpture", firstPriceFormat, 0)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Item sculpture is not registered")
+
+	err = at.createAuctionOf(bidder1, "auction1", "painting", firstPriceFormat, 0)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not the owner of item painting")
+
+	err = at.submit(fakeledger.Transaction{Client: bidder1}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.RegisterItem(ctx, "painting", "Forgery")
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "already registered")
+
+	// The item is locked by the first auction that sells it.
+	at.createAuction("auction1", firstPriceFormat, 0)
+	require.Equal(t, "auction1", at.item("painting").AuctionID)
+
+	err = at.createAuctionOf(seller, "auction2", "painting", firstPriceFormat, 0)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "already sold by auction auction1")
+}
+
+func TestBeforeTransactionChecksRole(t *testing.T) {
+	tests := []struct {
+		function string
+		role     string
+		allowed  bool
+	}{
+		{"CreateAuction", "seller", true},
+		{"CreateAuction", "bidder", false},
+		{"CreateAuction", "", false},
+		{"AuctionContract:createAuction", "bidder", false},
+		{"CreateBid", "seller, bidder", true},
+		{"CreateBid", "auctioneer", false},
+		{"CreateDoubleAuction", "auctioneer", true},
+		{"CloseAuction", "", true},
+		{"QueryAuction", "", true},
+	}
+
+	at := newAuctionTest(t)
+	checkRole := at.contract.GetBeforeTransaction().(func(contractapi.TransactionContextInterface) error)
+
+	for _, test := range tests {
+		client := fakeledger.NewIdentity("Org1MSP", "client")
+		if test.role != "" {
+			client = fakeledger.NewIdentity("Org1MSP", "client", roleAttribute, test.role)
+		}
+
+		_, err := at.ledger.Execute(fakeledger.Transaction{Client: client, Function: test.function}, checkRole)
+		if test.allowed {
+			require.NoError(t, err, test.function)
+		} else {
+			require.Error(t, err, test.function)
+			require.Contains(t, err.Error(), "Permission denied")
+		}
+	}
+}
+
+func TestAuctioneerClosesAndEndsAuctionForSeller(t *testing.T) {
+	at := newAuctionTest(t)
+	at.createAuction("auction1", firstPriceFormat, 100)
+
+	bid := at.bid("auction1", bidder2, 300)
+
+	// Only an auctioneer of the organization of the seller can act for the seller.
+	for _, client := range []*fakeledger.Identity{bidder1, fakeledger.NewIdentity("Org2MSP", "auctioneer", roleAttribute, auctioneerRole)} {
+		err := at.submit(fakeledger.Transaction{Client: client}, func(ctx contractapi.TransactionContextInterface) error {
+			return at.contract.CloseAuction(ctx, "auction1")
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "can only be closed by seller or auctioneer")
+	}
+
+	auctioneer := fakeledger.NewIdentity("Org1MSP", "auctioneer", roleAttribute, auctioneerRole)
+	err := at.submit(fakeledger.Transaction{Client: auctioneer}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CloseAuction(ctx, "auction1")
+	})
+	require.NoError(t, err)
+	require.NoError(t, at.reveal("auction1", bidder2, bid))
+
+	// The seller gives the reserve to the auctioneer that ends the auction.
+	err = at.submit(fakeledger.Transaction{Client: auctioneer, Transient: map[string][]byte{"reserve": at.reserve(100)}}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.EndAuction(ctx, "auction1")
+	})
+	require.NoError(t, err)
+
+	auction := at.query("auction1")
+	require.Equal(t, "ended", auction.Status)
+	require.Equal(t, bidder2.ID(), auction.Winner)
+	require.Equal(t, 300, at.balance(seller))
+}
+
+func TestInviteOnlyAuction(t *testing.T) {
+	at := newAuctionTest(t)
+
+	now := at.ledger.Clock.Unix()
+	createAuction := func(invitees ...string) error {
+		return at.submit(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+			return at.contract.CreateAuction(ctx, "auction1", "painting", firstPriceFormat, now+3600, now+7200, deposit, 1, invitees)
+		})
+	}
+	require.Error(t, createAuction("Org2MSP", "Org2MSP"))
+	require.Error(t, createAuction(""))
+	require.NoError(t, createAuction(bidder1.ID(), "Org2MSP"))
+
+	at.bid("auction1", bidder1, 100)
+	at.bid("auction1", bidder2, 200)
+
+	_, err := at.bidWithHold("auction1", bidder3, 300, maxBidPrice)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not invited to auction auction1")
+
+	addInvitee := func(client *fakeledger.Identity, invitee string) error {
+		return at.submit(fakeledger.Transaction{Client: client}, func(ctx contractapi.TransactionContextInterface) error {
+			return at.contract.AddInvitee(ctx, "auction1", invitee)
+		})
+	}
+
+	queryAs := func(client *fakeledger.Identity) *Auction {
+		var auction *Auction
+		_, err := at.ledger.Execute(fakeledger.Transaction{Client: client}, func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			auction, err = at.contract.QueryAuction(ctx, "auction1")
+			return err
+		})
+		require.NoError(t, err)
+
+		return auction
+	}
+
+	// The invitees are only shown to the seller and to the invitees.
+	require.Equal(t, []string{bidder1.ID(), "Org2MSP"}, queryAs(seller).Invitees)
+	require.Equal(t, []string{bidder1.ID(), "Org2MSP"}, queryAs(bidder2).Invitees)
+	require.True(t, queryAs(bidder3).InviteOnly)
+	require.Nil(t, queryAs(bidder3).Invitees)
+
+	var result *AuctionQueryResult
+	_, err = at.ledger.Execute(fakeledger.Transaction{Client: bidder3}, func(ctx contractapi.TransactionContextInterface) error {
+		result, err = at.contract.ListAuctionsBySeller(ctx, seller.ID(), 10, "")
+		return err
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Auctions, 1)
+	require.Nil(t, result.Auctions[0].Invitees)
+
+	err = addInvitee(bidder1, "Org3MSP")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "only the seller can add invitees")
+
+	require.NoError(t, addInvitee(seller, "Org3MSP"))
+	require.Error(t, addInvitee(seller, "Org3MSP"))
+	at.bid("auction1", bidder3, 300)
+
+	var history []*AuctionHistoryEntry
+	_, err = at.ledger.Execute(fakeledger.Transaction{Client: bidder2}, func(ctx contractapi.TransactionContextInterface) error {
+		history, err = at.contract.GetAuctionHistory(ctx, "auction1")
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, inviteeAddedStep, history[len(history)-2].Step)
+	require.Equal(t, []string{bidder1.ID(), "Org2MSP"}, history[0].Auction.Invitees)
+
+	// A client that was never invited does not see the invitees in the history.
+	outsider := fakeledger.NewIdentity("Org4MSP", "outsider")
+	_, err = at.ledger.Execute(fakeledger.Transaction{Client: outsider}, func(ctx contractapi.TransactionContextInterface) error {
+		history, err = at.contract.GetAuctionHistory(ctx, "auction1")
+		return err
+	})
+	require.NoError(t, err)
+	for _, entry := range history {
+		require.Nil(t, entry.Auction.Invitees)
+	}
+
+	// Invitees can only be added while the auction is open.
+	at.ledger.Advance(time.Hour)
+	at.close("auction1")
+	require.Error(t, addInvitee(seller, bidder3.ID()))
+}
+
+func TestDutchAuction(t *testing.T) {
+	at := newAuctionTest(t)
+	now := at.ledger.Clock.Unix()
+
+	err := at.submit(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CreateDutchAuction(ctx, "auction1", "painting", 500, 200, 50, 60, now+3600)
+	})
+	require.NoError(t, err)
+	require.Equal(t, 500, at.query("auction1").Price)
+
+	// The price drops by the decrement every interval, down to the floor.
+	at.ledger.Advance(2*time.Minute + time.Second)
+	require.Equal(t, 400, at.query("auction1").Price)
+
+	endAuction := func(client *fakeledger.Identity, auctionID string) error {
+		return at.submit(fakeledger.Transaction{Client: client}, func(ctx contractapi.TransactionContextInterface) error {
+			return at.contract.EndAuction(ctx, auctionID)
+		})
+	}
+
+	// The auction is open until the deadline, even for the seller.
+	err = endAuction(seller, "auction1")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "before its deadline")
+
+	at.ledger.Advance(time.Hour)
+	err = at.submit(fakeledger.Transaction{Client: bidder1}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.AcceptPrice(ctx, "auction1")
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "deadline")
+
+	// Once the deadline has passed, anyone can end the auction unsold, which
+	// unlocks the item for the seller.
+	require.NoError(t, endAuction(bidder3, "auction1"))
+	at.requireEvent(AuctionEndedEvent, AuctionEvent{AuctionID: "auction1", Status: "ended", Outcome: unsoldOutcome})
+
+	auction := at.query("auction1")
+	require.Equal(t, "ended", auction.Status)
+	require.Equal(t, unsoldOutcome, auction.Outcome)
+	require.Empty(t, auction.Winner)
+	require.Equal(t, seller.ID(), at.item("painting").Owner)
+	require.Empty(t, at.item("painting").AuctionID)
+	require.Error(t, endAuction(bidder3, "auction1"))
+
+	// The first buyer to accept the price wins.
+	now = at.ledger.Clock.Unix()
+	err = at.submit(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CreateDutchAuction(ctx, "auction2", "painting", 500, 200, 50, 60, now+3600)
+	})
+	require.NoError(t, err)
+
+	err = at.submit(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CloseAuction(ctx, "auction2")
+	})
+	require.Error(t, err)
+
+	at.ledger.Advance(time.Hour - time.Second)
+	require.Equal(t, 200, at.query("auction2").Price)
+
+	err = at.submit(fakeledger.Transaction{Client: bidder2}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.AcceptPrice(ctx, "auction2")
+	})
+	require.NoError(t, err)
+
+	err = at.submit(fakeledger.Transaction{Client: bidder3}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.AcceptPrice(ctx, "auction2")
+	})
+	require.Error(t, err)
+
+	auction = at.query("auction2")
+	require.Equal(t, "ended", auction.Status)
+	require.Equal(t, bidder2.ID(), auction.Winner)
+	require.Equal(t, 200, auction.Price)
+	require.Equal(t, 200, at.balance(seller))
+	require.Equal(t, initialBalance-200, at.balance(bidder2))
+	require.Equal(t, bidder2.ID(), at.item("painting").Owner)
+}
+
+func TestEnglishAuction(t *testing.T) {
+	at := newAuctionTest(t)
+	now := at.ledger.Clock.Unix()
+
+	err := at.submit(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CreateEnglishAuction(ctx, "auction1", "painting", 100, 20, now+3600)
+	})
+	require.NoError(t, err)
+
+	openBid := func(bidder *fakeledger.Identity, price int) error {
+		return at.submit(fakeledger.Transaction{Client: bidder}, func(ctx contractapi.TransactionContextInterface) error {
+			return at.contract.PlaceOpenBid(ctx, "auction1", price)
+		})
+	}
+
+	// The first bid has to meet the start price, the others have to beat the
+	// high bid by the minimum increment.
+	require.Error(t, openBid(bidder2, 90))
+	require.NoError(t, openBid(bidder2, 100))
+	require.Error(t, openBid(bidder3, 110))
+	require.Error(t, openBid(bidder2, 200))
+	require.NoError(t, openBid(bidder3, 120))
+
+	auction := at.query("auction1")
+	require.Equal(t, bidder3.ID(), auction.Winner)
+	require.Equal(t, 120, auction.Price)
+	require.Equal(t, []string{"Org1MSP", "Org2MSP", "Org3MSP"}, auction.Orgs)
+	require.Len(t, auction.RevealedBids, 2)
+
+	// Only the tokens of the high bid are held.
+	require.Equal(t, initialBalance, at.balance(bidder2))
+	require.Equal(t, initialBalance-120, at.balance(bidder3))
+
+	require.NoError(t, openBid(bidder2, 140))
+	require.Equal(t, initialBalance, at.balance(bidder3))
+
+	// Every open bid is a step of the history, including the bid of a bidder
+	// whose organization already bids.
+	var history []*AuctionHistoryEntry
+	_, err = at.ledger.Execute(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		history, err = at.contract.GetAuctionHistory(ctx, "auction1")
+		return err
+	})
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	require.Equal(t, createdStep, history[0].Step)
+	for _, entry := range history[1:] {
+		require.Equal(t, bidSubmittedStep, entry.Step)
+	}
+	require.Equal(t, 140, history[3].Auction.Price)
+
+	// The bids are public, so the auction ends without a reveal phase, and it
+	// needs the endorsement of every bidding organization.
+	endAuction := func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.EndAuction(ctx, "auction1")
+	}
+
+	// The seller cannot stop the auction before the deadline, while the other
+	// bidders can still outbid the high bid.
+	err = at.submit(fakeledger.Transaction{Client: seller}, endAuction)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "before its deadline")
+	err = at.submit(fakeledger.Transaction{Client: seller}, func(ctx contractapi.TransactionContextInterface) error {
+		return at.contract.CloseAuction(ctx, "auction1")
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "before its deadline")
+
+	at.ledger.Advance(time.Hour + time.Second)
+	require.Error(t, openBid(bidder3, 200))
+
+	err = at.ledger.SubmitEndorsed(fakeledger.Transaction{Client: seller}, []string{"Org1MSP", "Org2MSP"}, endAuction)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "state validation parameter not satisfied")
+
+	err = at.ledger.SubmitEndorsed(fakeledger.Transaction{Client: seller}, []string{"Org1MSP", "Org2MSP", "Org3MSP"}, endAuction)
+	require.NoError(t, err)
+
+	auction = at.query("auction1")
+	require.Equal(t, "ended", auction.Status)
+	require.Equal(t, soldOutcome, auction.Outcome)
+	require.Equal(t, bidder2.ID(), auction.Winner)
+	require.Equal(t, 140, auction.Price)
+	require.Equal(t, 140, at.balance(seller))
+	require.Equal(t, initialBalance-140, at.balance(bidder2))
+	require.Equal(t, bidder2.ID(), at.item("painting").Owner)
+}
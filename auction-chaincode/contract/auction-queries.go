@@ -0,0 +1,285 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AuctionQueryResult stores a page of auctions and the bookmark used to query
+// the next page.
+type AuctionQueryResult struct {
+	Auctions            []*Auction `json:"auctions"`
+	FetchedRecordsCount int32      `json:"fetchedRecordsCount"`
+	Bookmark            string     `json:"bookmark"`
+}
+
+// AuctionHistoryEntry stores a committed version of an auction, along with the
+// lifecycle step that produced it.
+type AuctionHistoryEntry struct {
+	TxID      string   `json:"txID"`
+	Timestamp int64    `json:"timestamp"`
+	IsDelete  bool     `json:"isDelete"`
+	Step      string   `json:"step"`
+	Auction   *Auction `json:"auction,omitempty"`
+}
+
+// Lifecycle steps reported in the auction history. Sealed bids are stored under
+// their own keys and are not part of the history. An organization joins the
+// auction with the first bid of its members, and leaves it when the last one is
+// withdrawn. Every open bid on an english auction is a step, since it updates
+// the high bid.
+const (
+	createdStep      = "created"
+	bidSubmittedStep = "bid submitted"
+	orgJoinedStep    = "organization joined"
+	orgLeftStep      = "organization left"
+	closedStep       = "closed"
+	endedStep        = "ended"
+	cancelledStep    = "cancelled"
+	inviteeAddedStep = "invitee added"
+	deletedStep      = "deleted"
+	updatedStep      = "updated"
+)
+
+// GetAuctionHistory returns every committed version of the auction, from the
+// oldest to the newest, with the transaction that committed it and the lifecycle
+// step that produced it. The history only covers the auction itself: sealed bids
+// are stored under their own keys, and their submissions, reveals and
+// withdrawals are not part of it. The invitees of an invite-only auction are
+// only shown to the seller and to the invitees.
+func (c *AuctionContract) GetAuctionHistory(ctx contractapi.TransactionContextInterface, auctionID string) ([]*AuctionHistoryEntry, error) {
+	iterator, err := ctx.GetStub().GetHistoryForKey(auctionID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get history of auction %v: %v", auctionID, err)
+	}
+	defer iterator.Close()
+
+	// The history is returned from the newest to the oldest version.
+	history := []*AuctionHistoryEntry{}
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to iterate history of auction %v: %v", auctionID, err)
+		}
+
+		entry := &AuctionHistoryEntry{
+			TxID:      modification.TxId,
+			Timestamp: modification.Timestamp.GetSeconds(),
+			IsDelete:  modification.IsDelete,
+		}
+
+		if !modification.IsDelete {
+			auction := new(Auction)
+
+			err = json.Unmarshal(modification.Value, auction)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to unmarshal auction %v in transaction %v: %v", auctionID, modification.TxId, err)
+			}
+			auction.ID = auctionID
+
+			entry.Auction = auction
+		}
+
+		history = append([]*AuctionHistoryEntry{entry}, history...)
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("Auction %v does not exist", auctionID)
+	}
+
+	// Compare each version with the previous one to find the lifecycle step.
+	var previous *Auction
+	for _, entry := range history {
+		entry.Step = historyStep(previous, entry)
+		previous = entry.Auction
+	}
+
+	// The invitees are hidden once the steps are known, as for QueryAuction.
+	for _, entry := range history {
+		err = hideInvitees(ctx, entry.Auction)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return history, nil
+}
+
+// historyStep is an internal function that returns the lifecycle step that
+// produced a version of an auction, given the previous version.
+func historyStep(previous *Auction, entry *AuctionHistoryEntry) string {
+	switch {
+	case entry.IsDelete:
+		return deletedStep
+	case previous == nil:
+		return createdStep
+	case previous.Status != entry.Auction.Status:
+		switch entry.Auction.Status {
+		case "closed":
+			return closedStep
+		case "ended":
+			return endedStep
+		case "cancelled":
+			return cancelledStep
+		}
+		return entry.Auction.Status
+	case entry.Auction.Winner != previous.Winner || entry.Auction.Price != previous.Price:
+		return bidSubmittedStep
+	case len(entry.Auction.Orgs) > len(previous.Orgs):
+		return orgJoinedStep
+	case len(entry.Auction.Orgs) < len(previous.Orgs):
+		return orgLeftStep
+	case len(entry.Auction.Invitees) > len(previous.Invitees):
+		return inviteeAddedStep
+	}
+
+	return updatedStep
+}
+
+// maxPageSize is the largest page of auctions that can be listed. Every auction
+// of a page is read along with its private and revealed bids, so a page reads up
+// to maxPageSize auctions and every bid of those auctions.
+const maxPageSize = 50
+
+// ListAuctionsByStatus returns a page of the auctions with the given status. Pass
+// the bookmark of the previous page to get the next page, or an empty bookmark
+// to get the first page.
+func (c *AuctionContract) ListAuctionsByStatus(ctx contractapi.TransactionContextInterface, status string, pageSize int32, bookmark string) (*AuctionQueryResult, error) {
+	return c.listAuctionsByIndex(ctx, statusIndexType, status, pageSize, bookmark)
+}
+
+// ListAuctionsBySeller returns a page of the auctions created by the given seller.
+func (c *AuctionContract) ListAuctionsBySeller(ctx contractapi.TransactionContextInterface, seller string, pageSize int32, bookmark string) (*AuctionQueryResult, error) {
+	return c.listAuctionsByIndex(ctx, sellerIndexType, seller, pageSize, bookmark)
+}
+
+// ListAuctionsByItem returns a page of the auctions that sell the given item.
+func (c *AuctionContract) ListAuctionsByItem(ctx contractapi.TransactionContextInterface, item string, pageSize int32, bookmark string) (*AuctionQueryResult, error) {
+	return c.listAuctionsByIndex(ctx, itemIndexType, item, pageSize, bookmark)
+}
+
+// SearchAuctions returns a page of the auctions matching the given status, seller
+// and item using a CouchDB rich query. Empty filters are ignored. This transaction
+// is only supported when the peers use CouchDB as state database.
+func (c *AuctionContract) SearchAuctions(ctx contractapi.TransactionContextInterface, status string, seller string, item string, pageSize int32, bookmark string) (*AuctionQueryResult, error) {
+	err := validatePageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the selector from the filters that are set.
+	selector := map[string]string{"objectType": "auction"}
+	if status != "" {
+		selector["status"] = status
+	}
+	if seller != "" {
+		selector["seller"] = seller
+	}
+	if item != "" {
+		selector["item"] = item
+	}
+
+	query, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to marshal query: %v", err)
+	}
+
+	iterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(string(query), pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to query auctions: %v", err)
+	}
+	defer iterator.Close()
+
+	auctions, err := c.collectAuctions(ctx, iterator, func(key string) (string, error) {
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuctionQueryResult{
+		Auctions:            auctions,
+		FetchedRecordsCount: metadata.FetchedRecordsCount,
+		Bookmark:            metadata.Bookmark,
+	}, nil
+}
+
+// listAuctionsByIndex is an internal function that returns a page of the auctions
+// found under the given value of an index.
+func (c *AuctionContract) listAuctionsByIndex(ctx contractapi.TransactionContextInterface, indexType string, value string, pageSize int32, bookmark string) (*AuctionQueryResult, error) {
+	err := validatePageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(indexType, []string{value}, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get %v index: %v", indexType, err)
+	}
+	defer iterator.Close()
+
+	// The auction ID is the last attribute of the index key.
+	auctions, err := c.collectAuctions(ctx, iterator, func(key string) (string, error) {
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(key)
+		if err != nil {
+			return "", fmt.Errorf("Failed to split composite key %v: %v", key, err)
+		}
+		if len(attributes) != 2 {
+			return "", fmt.Errorf("Unexpected index key %v", key)
+		}
+
+		return attributes[1], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuctionQueryResult{
+		Auctions:            auctions,
+		FetchedRecordsCount: metadata.FetchedRecordsCount,
+		Bookmark:            metadata.Bookmark,
+	}, nil
+}
+
+// validatePageSize is an internal utility function to check that a page size is
+// positive and at most maxPageSize.
+func validatePageSize(pageSize int32) error {
+	if pageSize <= 0 {
+		return fmt.Errorf("Page size must be positive, got %v", pageSize)
+	}
+	if pageSize > maxPageSize {
+		return fmt.Errorf("Page size must be at most %v, got %v", maxPageSize, pageSize)
+	}
+
+	return nil
+}
+
+// collectAuctions is an internal function that reads the auction of every key
+// returned by the iterator, along with its bids. The page size of the query
+// bounds the number of auctions read.
+func (c *AuctionContract) collectAuctions(ctx contractapi.TransactionContextInterface, iterator shim.StateQueryIteratorInterface, auctionIDFromKey func(key string) (string, error)) ([]*Auction, error) {
+	auctions := []*Auction{}
+
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to iterate auctions: %v", err)
+		}
+
+		auctionID, err := auctionIDFromKey(response.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		auction, err := c.QueryAuction(ctx, auctionID)
+		if err != nil {
+			return nil, err
+		}
+
+		auctions = append(auctions, auction)
+	}
+
+	return auctions, nil
+}
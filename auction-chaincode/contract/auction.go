@@ -2,13 +2,118 @@ package contract
 
 // Auction stores auction's data
 type Auction struct {
-	Type         string             `json:"objectType"`
-	ItemSold     string             `json:"item"`
-	Seller       string             `json:"seller"`
-	Orgs         []string           `json:"organizations"`
-	PrivateBids  map[string]BidHash `json:"privateBids"`
-	RevealedBids map[string]FullBid `json:"revealedBids"`
-	Winner       string             `json:"winner"`
-	Price        int                `json:"price"`
-	Status       string             `json:"status"`
+	Type            string             `json:"objectType"`
+	ID              string             `json:"id"`
+	ItemSold        string             `json:"item"`
+	Quantity        int                `json:"quantity,omitempty"`
+	Format          string             `json:"format"`
+	Seller          string             `json:"seller"`
+	ReserveHash     string             `json:"reserveHash"`
+	Orgs            []string           `json:"organizations"`
+	PrivateBids     map[string]BidHash `json:"privateBids,omitempty"`
+	RevealedBids    map[string]FullBid `json:"revealedBids,omitempty"`
+	Winner          string             `json:"winner"`
+	WinningBid      int                `json:"winningBid"`
+	Price           int                `json:"price"`
+	Status          string             `json:"status"`
+	BiddingDeadline int64              `json:"biddingDeadline"`
+	RevealDeadline  int64              `json:"revealDeadline"`
+	Deposit         int                `json:"deposit"`
+	Outcome         string             `json:"outcome"`
+	Allocations     []Allocation       `json:"allocations,omitempty"`
+	Matches         []Match            `json:"matches,omitempty"`
+	TieBreak        string             `json:"tieBreak,omitempty"`
+	Tie             bool               `json:"tie"`
+	InviteOnly      bool               `json:"inviteOnly"`
+	Invitees        []string           `json:"invitees,omitempty"`
+
+	// Price schedule of a dutch auction. The price starts at the start price at
+	// the start time, and drops by the decrement every interval, in seconds,
+	// until it reaches the floor price.
+	StartPrice        int   `json:"startPrice,omitempty"`
+	FloorPrice        int   `json:"floorPrice,omitempty"`
+	PriceDecrement    int   `json:"priceDecrement,omitempty"`
+	DecrementInterval int64 `json:"decrementInterval,omitempty"`
+	StartTime         int64 `json:"startTime,omitempty"`
+
+	// Minimum increment of the bids of an english auction. The start price is
+	// the minimum of the first bid.
+	MinIncrement int `json:"minIncrement,omitempty"`
+}
+
+// Allocation stores the units of an ended auction won by a bid, and the unit
+// price paid for them. Every winner of a sealed-bid auction pays the same
+// clearing price.
+type Allocation struct {
+	BidKey   string `json:"bidKey,omitempty"`
+	Bidder   string `json:"bidder"`
+	Org      string `json:"org"`
+	Quantity int    `json:"quantity"`
+	Price    int    `json:"price"`
 }
+
+// Match stores units of a double auction traded between a buy order and a sell
+// order, and the unit price paid for them. Every match trades at the same
+// clearing price.
+type Match struct {
+	BidKey    string `json:"bidKey"`
+	Buyer     string `json:"buyer"`
+	BuyerOrg  string `json:"buyerOrg"`
+	AskKey    string `json:"askKey"`
+	Seller    string `json:"seller"`
+	SellerOrg string `json:"sellerOrg"`
+	Quantity  int    `json:"quantity"`
+	Price     int    `json:"price"`
+}
+
+// Reserve stores the seller's private reserve price. Like a bid, the reserve
+// carries a random salt, so that the price cannot be guessed from the reserve
+// hash published on the auction.
+type Reserve struct {
+	Type   string `json:"objectType"`
+	Price  int    `json:"price"`
+	Seller string `json:"seller"`
+	Salt   string `json:"salt"`
+}
+
+// Auction formats supported by the contract. In a first-price auction the
+// winner pays their own bid, in a second-price (Vickrey) auction the winner
+// pays the second highest revealed bid. Sealed-bid auctions of several units
+// charge every winner a uniform unit price: the lowest winning bid in a
+// first-price auction, and the highest losing bid in a second-price auction. A
+// dutch auction has no bids, the first buyer to accept its descending price
+// wins. An english auction has public ascending bids, and the high bid wins. A
+// double auction matches sealed buy and sell orders at a uniform clearing price.
+const (
+	firstPriceFormat  = "first-price"
+	secondPriceFormat = "second-price"
+	dutchFormat       = "dutch"
+	englishFormat     = "english"
+	doubleFormat      = "double"
+)
+
+// Outcomes of an ended auction. An auction with a reserve price ends without
+// a winner if no revealed bid meets the reserve, a double auction ends without
+// trades if no revealed buy order meets a revealed sell order, and a dutch
+// auction ends unsold if no buyer accepts the price before the deadline.
+const (
+	soldOutcome          = "sold"
+	reserveNotMetOutcome = "reserve-not-met"
+	noMatchOutcome       = "no-match"
+	unsoldOutcome        = "unsold"
+)
+
+// tieBreakRule orders the bids of the same price of a sealed-bid auction: the
+// bid submitted first comes first, and bids submitted at the same timestamp are
+// ordered by bid key.
+const tieBreakRule = "earliest-submission-then-bid-key"
+
+const reserveKeyType = "reserve"
+
+// Object types of the composite keys used to look up auctions by status, seller
+// and item sold.
+const (
+	statusIndexType = "status~auction"
+	sellerIndexType = "seller~auction"
+	itemIndexType   = "item~auction"
+)
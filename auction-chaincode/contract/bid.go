@@ -1,17 +1,41 @@
 package contract
 
-// FullBid stores revealed bid's data
+// FullBid stores revealed bid's data. The price is the price of one unit. The
+// orders of a double auction also have a side.
 type FullBid struct {
-	Type   string `json:"objectType"`
-	Price  int    `json:"price"`
-	Org    string `json:"org"`
-	Bidder string `json:"bidder"`
+	Type     string `json:"objectType"`
+	Price    int    `json:"price"`
+	Quantity int    `json:"quantity"`
+	Side     string `json:"side,omitempty"`
+	Org      string `json:"org"`
+	Bidder   string `json:"bidder"`
+	Salt     string `json:"salt,omitempty"`
 }
 
-// BidHash stores private bid's data
+// BidHash stores private bid's data, and the timestamp of the transaction that
+// submitted it.
 type BidHash struct {
-	Org  string `json:"org"`
-	Hash string `json:"hash"`
+	Org       string `json:"org"`
+	Hash      string `json:"hash"`
+	Timestamp int64  `json:"timestamp"`
 }
 
 const bidKeyType = "bid"
+
+// Sides of the orders of a double auction. A bid buys units at most at its unit
+// price, and an ask sells units at least at its unit price.
+const (
+	bidSide = "bid"
+	askSide = "ask"
+)
+
+// Object types of the keys that store the private and revealed bids of an
+// auction, indexed by auction ID and transaction ID of the bid.
+const (
+	privateBidKeyType  = "privateBid"
+	revealedBidKeyType = "revealedBid"
+)
+
+// minSaltLength is the minimum number of random bytes in the salt of a bid. The
+// salt is hex encoded in the bid.
+const minSaltLength = 16
@@ -0,0 +1,256 @@
+package contract
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxInt is the largest price of an order.
+const maxInt = int(^uint(0) >> 1)
+
+// CreateDoubleAuction creates a double auction on the public channel, a call
+// market where buyers and sellers trade units of an item with sealed orders.
+// Orders are created, submitted and revealed like the bids of a sealed-bid
+// auction, with a side: a bid buys units and an ask sells them. The identity
+// that submits the transaction runs the market, it can close and clear the
+// auction before the deadlines and is paid the deposits of the orders that are
+// not revealed in time. The deposit must be positive, as it is the only stake of
+// a sell order. The item names the units traded, it is not locked in the item
+// registry.
+func (c *AuctionContract) CreateDoubleAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, biddingDeadline int64, revealDeadline int64, deposit int) error {
+	if deposit <= 0 {
+		return fmt.Errorf("Deposit of a double auction must be positive, got %v", deposit)
+	}
+
+	// Check that the deadlines are in the future and in order.
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+	if biddingDeadline <= now {
+		return fmt.Errorf("Bidding deadline %v must be after the transaction timestamp %v", biddingDeadline, now)
+	}
+	if revealDeadline <= biddingDeadline {
+		return fmt.Errorf("Reveal deadline %v must be after the bidding deadline %v", revealDeadline, biddingDeadline)
+	}
+
+	// Get ID of submitting client identity.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
+	// Get Org of submitting client identity.
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("Failed to get Org client identity: %v", err)
+	}
+
+	auction := Auction{
+		Type:            "auction",
+		ID:              auctionID,
+		ItemSold:        itemsold,
+		Format:          doubleFormat,
+		Seller:          clientID,
+		Orgs:            []string{clientOrgID},
+		Status:          "open",
+		BiddingDeadline: biddingDeadline,
+		RevealDeadline:  revealDeadline,
+		Deposit:         deposit,
+		TieBreak:        tieBreakRule,
+	}
+
+	return putNewAuction(ctx, &auction)
+}
+
+// ClearAuction clears a closed double auction. The revealed bids are matched
+// with the revealed asks, from the highest bid and the lowest ask, for as long
+// as the bid meets the ask. Every match trades at the same clearing price, half
+// way between the last bid and the last ask matched, which is at most the price
+// of every matched bid and at least the price of every matched ask. Buyers pay
+// their sellers from the tokens held for their bids, and every other hold is
+// returned. As for EndAuction, the organizations do not endorse the clearing
+// while an unrevealed order of their members would trade or change the price,
+// until the reveal deadline has passed.
+func (c *AuctionContract) ClearAuction(ctx contractapi.TransactionContextInterface, auctionID string) error {
+	// Get auction from public state.
+	auction, err := getAuctionWithBids(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("Failed to get auction from public state: %v", err)
+	}
+
+	if auction.Format != doubleFormat {
+		return fmt.Errorf("Cannot clear %v auction, only double auctions are cleared", auction.Format)
+	}
+
+	// Check that the auction is being cleared by the identity that runs it or an
+	// auctioneer of its organization, or by anyone once the reveal deadline has
+	// passed.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get submitting client identity: %v", err)
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+
+	forSeller, err := actsForSeller(ctx, auction, clientID)
+	if err != nil {
+		return err
+	}
+	if !forSeller && now <= auction.RevealDeadline {
+		return fmt.Errorf("Auction can only be cleared by seller or auctioneer before the reveal deadline %v", auction.RevealDeadline)
+	}
+
+	if auction.Status != "closed" {
+		return fmt.Errorf("Cannot clear auction that is not closed")
+	}
+
+	if len(auction.RevealedBids) == 0 {
+		return fmt.Errorf("No orders have been revealed, cannot clear auction")
+	}
+
+	// Get the tokens held for the orders of the auction.
+	holds, err := getHolds(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("Failed to get holds of auction %v: %v", auctionID, err)
+	}
+
+	matches, clearingPrice, minBid, maxAsk := matchOrders(auction)
+
+	// Check if there is an order that has yet to be revealed and that would trade
+	// or change the clearing price. A bid above the tokens held for it can never
+	// be revealed, so it is ignored.
+	err = checkUnrevealedBids(ctx, auction.RevealedBids, auction.PrivateBids, auction.RevealDeadline, func(bidKey string, bid *FullBid) error {
+		if bid.Side == bidSide && holdCoversBid(holds[bidKey], bid.Price, bid.Quantity) && bid.Price >= minBid {
+			return fmt.Errorf("Cannot clear auction, bid would change the clearing: %v", bidKey)
+		}
+		if bid.Side == askSide && bid.Price <= maxAsk {
+			return fmt.Errorf("Cannot clear auction, ask would change the clearing: %v", bidKey)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to check for unrevealed orders, cannot clear auction: %v", err)
+	}
+
+	auction.Matches = matches
+	auction.Price = clearingPrice
+	auction.Outcome = soldOutcome
+	if len(matches) == 0 {
+		auction.Outcome = noMatchOutcome
+	}
+
+	// Pay the sellers and release the other holds.
+	forfeited, err := forfeitedBids(ctx, auction, holds)
+	if err != nil {
+		return err
+	}
+
+	err = settleHolds(ctx, auction, holds, forfeited)
+	if err != nil {
+		return fmt.Errorf("Failed to settle auction payment: %v", err)
+	}
+
+	return endAuction(ctx, auction, "")
+}
+
+// matchOrders is an internal utility function to match the revealed bids of a
+// double auction with its revealed asks. It returns the matches, the clearing
+// price, the lowest price of an unrevealed bid that would trade or change the
+// clearing, and the highest price of an unrevealed ask that would.
+func matchOrders(auction *Auction) ([]Match, int, int, int) {
+	// Sort the bids from the highest price and the asks from the lowest price, so
+	// that every peer matches the orders in the same order.
+	bidKeys := []string{}
+	askKeys := []string{}
+	for bidKey, order := range auction.RevealedBids {
+		if order.Side == askSide {
+			askKeys = append(askKeys, bidKey)
+		} else {
+			bidKeys = append(bidKeys, bidKey)
+		}
+	}
+	sortBidKeys(auction, bidKeys, true)
+	sortBidKeys(auction, askKeys, false)
+
+	matches := []Match{}
+	lastBid, lastAsk := 0, 0
+	bidLeft, askLeft := 0, 0
+	i, j := 0, 0
+	if len(bidKeys) > 0 {
+		bidLeft = auction.RevealedBids[bidKeys[0]].Quantity
+	}
+	if len(askKeys) > 0 {
+		askLeft = auction.RevealedBids[askKeys[0]].Quantity
+	}
+
+	for i < len(bidKeys) && j < len(askKeys) {
+		bid := auction.RevealedBids[bidKeys[i]]
+		ask := auction.RevealedBids[askKeys[j]]
+		if bid.Price < ask.Price {
+			break
+		}
+
+		units := bidLeft
+		if askLeft < units {
+			units = askLeft
+		}
+
+		matches = append(matches, Match{
+			BidKey:    bidKeys[i],
+			Buyer:     bid.Bidder,
+			BuyerOrg:  bid.Org,
+			AskKey:    askKeys[j],
+			Seller:    ask.Bidder,
+			SellerOrg: ask.Org,
+			Quantity:  units,
+		})
+		lastBid, lastAsk = bid.Price, ask.Price
+
+		bidLeft -= units
+		if bidLeft == 0 {
+			i++
+			if i < len(bidKeys) {
+				bidLeft = auction.RevealedBids[bidKeys[i]].Quantity
+			}
+		}
+
+		askLeft -= units
+		if askLeft == 0 {
+			j++
+			if j < len(askKeys) {
+				askLeft = auction.RevealedBids[askKeys[j]].Quantity
+			}
+		}
+	}
+
+	clearingPrice := 0
+	if len(matches) > 0 {
+		clearingPrice = lastAsk + (lastBid-lastAsk)/2
+	}
+	for k := range matches {
+		matches[k].Price = clearingPrice
+	}
+
+	// An unrevealed bid would trade if it meets the next ask with units left, and
+	// would change the clearing if it is above the last bid matched. Unrevealed
+	// asks are checked the other way around. No unrevealed order trades if there
+	// is no revealed order to trade with.
+	minBid, maxAsk := maxInt, 0
+	if len(matches) > 0 {
+		minBid, maxAsk = lastBid+1, lastAsk-1
+	}
+	if j < len(askKeys) && auction.RevealedBids[askKeys[j]].Price < minBid {
+		minBid = auction.RevealedBids[askKeys[j]].Price
+	}
+	if i < len(bidKeys) && auction.RevealedBids[bidKeys[i]].Price > maxAsk {
+		maxAsk = auction.RevealedBids[bidKeys[i]].Price
+	}
+
+	return matches, clearingPrice, minBid, maxAsk
+}
@@ -0,0 +1,166 @@
+package contract
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CreateDutchAuction creates a dutch auction on the public channel. The identity
+// that submits the transaction becomes the seller, and the item sold is locked
+// as for a sealed-bid auction. The price starts at the start price, and drops by
+// the decrement every interval, given in seconds, until it reaches the floor
+// price. The first buyer to accept the price before the deadline wins.
+func (c *AuctionContract) CreateDutchAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, startPrice int, floorPrice int, decrement int, interval int64, deadline int64) error {
+	// Check that the price schedule is valid.
+	if floorPrice <= 0 || floorPrice > startPrice {
+		return fmt.Errorf("Floor price must be positive and at most the start price %v, got %v", startPrice, floorPrice)
+	}
+	if decrement <= 0 {
+		return fmt.Errorf("Price decrement must be positive, got %v", decrement)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("Decrement interval must be positive, got %v", interval)
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+	if deadline <= now {
+		return fmt.Errorf("Deadline %v must be after the transaction timestamp %v", deadline, now)
+	}
+
+	// Get ID of submitting client identity.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
+	// Get Org of submitting client identity.
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("Failed to get Org client identity: %v", err)
+	}
+
+	// The auction has no reveal phase, it can be accepted until the deadline.
+	auction := Auction{
+		Type:              "auction",
+		ID:                auctionID,
+		ItemSold:          itemsold,
+		Quantity:          1,
+		Format:            dutchFormat,
+		Seller:            clientID,
+		Orgs:              []string{clientOrgID},
+		Status:            "open",
+		BiddingDeadline:   deadline,
+		RevealDeadline:    deadline,
+		StartPrice:        startPrice,
+		FloorPrice:        floorPrice,
+		PriceDecrement:    decrement,
+		DecrementInterval: interval,
+		StartTime:         now,
+	}
+
+	return putNewAuction(ctx, &auction)
+}
+
+// AcceptPrice buys the item of a dutch auction at its current price. The price
+// is paid from the token account of the buyer to the seller, the item is
+// transferred to the buyer, and the auction is ended.
+func (c *AuctionContract) AcceptPrice(ctx contractapi.TransactionContextInterface, auctionID string) error {
+	// Get auction from public state.
+	auction, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("Failed to get auction from public state: %v", err)
+	}
+
+	if auction.Format != dutchFormat {
+		return fmt.Errorf("Cannot accept the price of %v auction, only dutch auctions have a price to accept", auction.Format)
+	}
+
+	if auction.Status != "open" {
+		return fmt.Errorf("Cannot accept the price of auction that is not open")
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+	if now > auction.BiddingDeadline {
+		return fmt.Errorf("Cannot accept the price, deadline %v has passed", auction.BiddingDeadline)
+	}
+
+	// Get ID of submitting client identity.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+	if clientID == auction.Seller {
+		return fmt.Errorf("Seller cannot accept the price of their own auction")
+	}
+
+	// Get Org of submitting client identity.
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("Failed to get Org client identity: %v", err)
+	}
+
+	// Pay the current price to the seller.
+	auction.Price = dutchPrice(auction, now)
+
+	err = debitAccount(ctx, clientID, auction.Price)
+	if err != nil {
+		return fmt.Errorf("Failed to pay auction price: %v", err)
+	}
+
+	err = creditAccount(ctx, auction.Seller, auction.Price)
+	if err != nil {
+		return fmt.Errorf("Failed to pay auction price: %v", err)
+	}
+
+	auction.Winner = clientID
+	auction.WinningBid = auction.Price
+	auction.Allocations = []Allocation{{Bidder: clientID, Org: clientOrgID, Quantity: 1, Price: auction.Price}}
+	auction.Outcome = soldOutcome
+
+	// Transfer the item to the buyer.
+	return endAuction(ctx, auction, clientOrgID)
+}
+
+// endExpiredDutchAuction is an internal utility function to end a dutch auction
+// that no buyer accepted before its deadline. The auction ends unsold, and its
+// item is unlocked for the seller.
+func endExpiredDutchAuction(ctx contractapi.TransactionContextInterface, auction *Auction, now int64) error {
+	if now <= auction.BiddingDeadline {
+		return fmt.Errorf("Cannot end dutch auction before its deadline %v, it ends when a buyer accepts the price", auction.BiddingDeadline)
+	}
+
+	// Nothing was paid, so the auction keeps no price of its schedule.
+	auction.Price = 0
+	auction.Outcome = unsoldOutcome
+
+	return endAuction(ctx, auction, "")
+}
+
+// dutchPrice is an internal utility function to compute the price of a dutch
+// auction at a timestamp.
+func dutchPrice(auction *Auction, now int64) int {
+	if now <= auction.StartTime {
+		return auction.StartPrice
+	}
+
+	// Compare the number of decrements to the ones needed to reach the floor,
+	// so that the price cannot overflow.
+	steps := (now - auction.StartTime) / auction.DecrementInterval
+	if steps >= int64((auction.StartPrice-auction.FloorPrice)/auction.PriceDecrement)+1 {
+		return auction.FloorPrice
+	}
+
+	price := auction.StartPrice - int(steps)*auction.PriceDecrement
+	if price < auction.FloorPrice {
+		return auction.FloorPrice
+	}
+
+	return price
+}
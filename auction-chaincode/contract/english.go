@@ -0,0 +1,240 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CreateEnglishAuction creates an english auction on the public channel. The
+// identity that submits the transaction becomes the seller, and the item sold
+// is locked as for a sealed-bid auction. Bids are public, the first bid has to
+// be at least the start price, and every other bid has to beat the high bid by
+// the minimum increment. Bids can be placed until the deadline.
+func (c *AuctionContract) CreateEnglishAuction(ctx contractapi.TransactionContextInterface, auctionID string, itemsold string, startPrice int, minIncrement int, deadline int64) error {
+	if startPrice <= 0 {
+		return fmt.Errorf("Start price must be positive, got %v", startPrice)
+	}
+	if minIncrement <= 0 {
+		return fmt.Errorf("Minimum increment must be positive, got %v", minIncrement)
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+	if deadline <= now {
+		return fmt.Errorf("Deadline %v must be after the transaction timestamp %v", deadline, now)
+	}
+
+	// Get ID of submitting client identity.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
+	// Get Org of submitting client identity.
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("Failed to get Org client identity: %v", err)
+	}
+
+	// The auction has no reveal phase, it can be ended by anyone once bidding
+	// is over.
+	auction := Auction{
+		Type:            "auction",
+		ID:              auctionID,
+		ItemSold:        itemsold,
+		Quantity:        1,
+		Format:          englishFormat,
+		Seller:          clientID,
+		Orgs:            []string{clientOrgID},
+		Status:          "open",
+		BiddingDeadline: deadline,
+		RevealDeadline:  deadline,
+		StartPrice:      startPrice,
+		MinIncrement:    minIncrement,
+	}
+
+	return putNewAuction(ctx, &auction)
+}
+
+// PlaceOpenBid places a public bid on an english auction. The bid becomes the
+// high bid, so the price and the winner of the auction are updated. The price of
+// the bid is held from the token account of the bidder, and the hold of the
+// previous high bid is returned to its bidder. The organization of the bidder is
+// added to the organizations of the auction, as for sealed bids.
+func (c *AuctionContract) PlaceOpenBid(ctx contractapi.TransactionContextInterface, auctionID string, price int) error {
+	// Get the MSP ID of the bidder's org.
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("Failed to get client identity MSP ID: %v", err)
+	}
+
+	// Get ID of submitting client identity.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
+	// Get the auction from public state.
+	auction, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("Failed to get auction from public state: %v", err)
+	}
+
+	if auction.Format != englishFormat {
+		return fmt.Errorf("Cannot place open bid on %v auction, only english auctions have open bids", auction.Format)
+	}
+	if auction.Status != "open" {
+		return fmt.Errorf("Cannot bid on closed or ended auction")
+	}
+
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+	if now > auction.BiddingDeadline {
+		return fmt.Errorf("Cannot bid on auction, bidding deadline %v has passed", auction.BiddingDeadline)
+	}
+
+	if clientID == auction.Seller {
+		return fmt.Errorf("Seller cannot bid on their own auction")
+	}
+	// Reads do not see the writes of the transaction, so the hold of the high
+	// bid cannot be returned to the account that places the new bid.
+	if clientID == auction.Winner {
+		return fmt.Errorf("Bidder already has the high bid of the auction")
+	}
+
+	// Check that the bid beats the high bid.
+	minPrice := auction.StartPrice
+	if auction.Winner != "" {
+		minPrice = auction.Price + auction.MinIncrement
+	}
+	if price < minPrice {
+		return fmt.Errorf("Bid price must be at least %v, got %v", minPrice, price)
+	}
+
+	// Add the bidding organization to the list of participating organizations if it is not already.
+	if !contains(auction.Orgs, clientOrgID) {
+		err = addAuctionOrg(ctx, auction, clientOrgID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Return the tokens held for the previous high bid, and hold the price of
+	// the new one.
+	holds, err := getHolds(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("Failed to get holds of auction %v: %v", auctionID, err)
+	}
+
+	err = settleHolds(ctx, auction, holds, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to release hold of the previous high bid: %v", err)
+	}
+
+	txID := ctx.GetStub().GetTxID()
+
+	err = placeHold(ctx, auctionID, txID, Hold{Holder: clientID, Amount: price}, auction.Orgs)
+	if err != nil {
+		return fmt.Errorf("Failed to hold tokens for bid: %v", err)
+	}
+
+	// The bid is public, so it is stored as a revealed bid.
+	bid := FullBid{
+		Type:     bidKeyType,
+		Price:    price,
+		Quantity: 1,
+		Org:      clientOrgID,
+		Bidder:   clientID,
+	}
+
+	revealedBidKey, err := ctx.GetStub().CreateCompositeKey(revealedBidKeyType, []string{auctionID, txID})
+	if err != nil {
+		return fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	bidBytes, _ := json.Marshal(bid)
+
+	err = ctx.GetStub().PutState(revealedBidKey, bidBytes)
+	if err != nil {
+		return fmt.Errorf("Failed to put open bid in public data: %v", err)
+	}
+
+	// Updates to the bid need to be endorsed by the organizations of the auction.
+	err = setAssetStateBasedEndorsement(ctx, revealedBidKey, auction.Orgs...)
+	if err != nil {
+		return fmt.Errorf("Failed setting state based endorsement for open bid: %v", err)
+	}
+
+	// The bid is the new high bid of the auction.
+	auction.Winner = clientID
+	auction.WinningBid = price
+	auction.Price = price
+
+	err = putAuction(ctx, auctionID, auction)
+	if err != nil {
+		return fmt.Errorf("Failed to update auction state: %v", err)
+	}
+
+	bidKey, err := ctx.GetStub().CreateCompositeKey(bidKeyType, []string{auctionID, txID})
+	if err != nil {
+		return fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	// Notify clients that a bid was submitted.
+	err = setAuctionEvent(ctx, BidSubmittedEvent, AuctionEvent{AuctionID: auctionID, Status: auction.Status, BidKey: bidKey})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// endEnglishAuction is an internal utility function to end an english auction,
+// whose open bids are its revealed bids. The auction cannot be ended before its
+// deadline, even by the seller, so that every bidder can still outbid the high
+// bid. The high bid wins, and its price is paid to the seller from the tokens
+// held for it.
+func endEnglishAuction(ctx contractapi.TransactionContextInterface, auction *Auction, now int64) error {
+	if now <= auction.BiddingDeadline {
+		return fmt.Errorf("Cannot end english auction before its deadline %v", auction.BiddingDeadline)
+	}
+	if auction.Winner == "" {
+		return fmt.Errorf("No bids have been placed, cannot end auction")
+	}
+
+	// Only the high bid has tokens held for it.
+	holds, err := getHolds(ctx, auction.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to get holds of auction %v: %v", auction.ID, err)
+	}
+
+	winningBidKey := ""
+	for bidKey, hold := range holds {
+		if hold.Holder == auction.Winner {
+			winningBidKey = bidKey
+		}
+	}
+
+	winningBid := auction.RevealedBids[winningBidKey]
+	auction.Allocations = []Allocation{{
+		BidKey:   winningBidKey,
+		Bidder:   winningBid.Bidder,
+		Org:      winningBid.Org,
+		Quantity: 1,
+		Price:    auction.Price,
+	}}
+	auction.Outcome = soldOutcome
+
+	err = settleHolds(ctx, auction, holds, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to settle auction payment: %v", err)
+	}
+
+	return endAuction(ctx, auction, winningBid.Org)
+}
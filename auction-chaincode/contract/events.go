@@ -0,0 +1,58 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AuctionEventVersion is the version of the AuctionEvent schema. The version is
+// increased whenever a field is removed or its meaning changes, adding a field
+// does not change the version.
+const AuctionEventVersion = "1"
+
+// Names of the chaincode events emitted by the auction lifecycle transactions.
+const (
+	AuctionCreatedEvent   = "AuctionCreated"
+	BidSubmittedEvent     = "BidSubmitted"
+	AuctionClosedEvent    = "AuctionClosed"
+	BidRevealedEvent      = "BidRevealed"
+	AuctionEndedEvent     = "AuctionEnded"
+	AuctionCancelledEvent = "AuctionCancelled"
+	BidWithdrawnEvent     = "BidWithdrawn"
+)
+
+// AuctionEvent is the payload of every auction chaincode event. The event name
+// is repeated in the payload so that the payload can be handled on its own. Bid
+// prices, salts and reserve prices are never part of the payload, the winner
+// and the price are only set when the auction ends.
+type AuctionEvent struct {
+	Version   string `json:"version"`
+	Name      string `json:"name"`
+	AuctionID string `json:"auctionID"`
+	Status    string `json:"status"`
+	BidKey    string `json:"bidKey,omitempty"`
+	Winner    string `json:"winner,omitempty"`
+	Price     int    `json:"price,omitempty"`
+	Outcome   string `json:"outcome,omitempty"`
+}
+
+// setAuctionEvent is an internal utility function to set the chaincode event of
+// the transaction. Fabric keeps a single event per transaction.
+func setAuctionEvent(ctx contractapi.TransactionContextInterface, name string, event AuctionEvent) error {
+	event.Version = AuctionEventVersion
+	event.Name = name
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal %v event: %v", name, err)
+	}
+
+	err = ctx.GetStub().SetEvent(name, payload)
+	if err != nil {
+		return fmt.Errorf("Failed to set %v event: %v", name, err)
+	}
+
+	return nil
+}
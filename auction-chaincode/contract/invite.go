@@ -0,0 +1,99 @@
+package contract
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AddInvitee adds an organization, given by its MSP ID, or a client identity,
+// given by its ID, to the invitees of an invite-only auction. Only the seller can
+// add invitees, while the auction is open.
+func (c *AuctionContract) AddInvitee(ctx contractapi.TransactionContextInterface, auctionID string, invitee string) error {
+	// Get the auction from public state.
+	auction, err := getAuction(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("Failed to get auction from public state: %v", err)
+	}
+
+	// Get ID of submitting client identity.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
+	if auction.Seller != clientID {
+		return fmt.Errorf("Permission denied, only the seller can add invitees to the auction")
+	}
+	if !auction.InviteOnly {
+		return fmt.Errorf("Auction %v is open to every bidder, it has no invitees", auctionID)
+	}
+	if auction.Status != "open" {
+		return fmt.Errorf("Cannot add invitees to auction that is not open")
+	}
+
+	if invitee == "" {
+		return fmt.Errorf("Invitee must not be empty")
+	}
+	if contains(auction.Invitees, invitee) {
+		return fmt.Errorf("%v is already invited to auction %v", invitee, auctionID)
+	}
+
+	auction.Invitees = append(auction.Invitees, invitee)
+
+	err = putAuction(ctx, auctionID, auction)
+	if err != nil {
+		return fmt.Errorf("Failed to update auction state: %v", err)
+	}
+
+	return nil
+}
+
+// validateInvitees is an internal utility function to check the invitees of a
+// new auction, which are MSP IDs of organizations and IDs of client identities.
+// An auction without invitees is open to every bidder.
+func validateInvitees(invitees []string) error {
+	for i, invitee := range invitees {
+		if invitee == "" {
+			return fmt.Errorf("Invitee must not be empty")
+		}
+		if contains(invitees[:i], invitee) {
+			return fmt.Errorf("Invitee %v is listed twice", invitee)
+		}
+	}
+
+	return nil
+}
+
+// isInvited is an internal utility function to check if a client can bid on an
+// auction, either as an invitee or as a member of an invited organization.
+func isInvited(auction *Auction, clientID string, clientOrgID string) bool {
+	if !auction.InviteOnly {
+		return true
+	}
+
+	return contains(auction.Invitees, clientID) || contains(auction.Invitees, clientOrgID)
+}
+
+// hideInvitees is an internal utility function to remove the invitees from the
+// auctions returned to the submitting client, unless it is the seller or one of
+// the invitees of the auction.
+func hideInvitees(ctx contractapi.TransactionContextInterface, auctions ...*Auction) error {
+	clientID, err := getSubmittingClientID(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("Failed to get client identity MSP ID: %v", err)
+	}
+
+	for _, auction := range auctions {
+		if auction != nil && auction.Seller != clientID && !isInvited(auction, clientID, clientOrgID) {
+			auction.Invitees = nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Item stores an item of the registry and its owner. An item that is sold by an
+// auction is locked by the ID of the auction, and cannot be sold by another
+// auction until the auction ends or is cancelled.
+type Item struct {
+	Type        string `json:"objectType"`
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Owner       string `json:"owner"`
+	OwnerOrg    string `json:"ownerOrg"`
+	AuctionID   string `json:"auctionID"`
+}
+
+const itemKeyType = "item"
+
+// RegisterItem adds an item to the registry. The identity that submits the
+// transaction becomes the owner of the item. Updates to the item need to be
+// endorsed by the organization of its owner.
+func (c *AuctionContract) RegisterItem(ctx contractapi.TransactionContextInterface, itemID string, description string) error {
+	if itemID == "" {
+		return fmt.Errorf("Item ID must not be empty")
+	}
+
+	// Get ID of submitting client identity.
+	clientID, err := c.GetSubmittingClientIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
+	// Get Org of submitting client identity.
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("Failed to get Org client identity: %v", err)
+	}
+
+	// Check that the item is not registered yet.
+	itemKey, err := ctx.GetStub().CreateCompositeKey(itemKeyType, []string{itemID})
+	if err != nil {
+		return fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	existing, err := ctx.GetStub().GetState(itemKey)
+	if err != nil {
+		return fmt.Errorf("Failed to get item %v: %v", itemID, err)
+	}
+	if existing != nil {
+		return fmt.Errorf("Item %v is already registered", itemID)
+	}
+
+	item := Item{
+		Type:        itemKeyType,
+		ID:          itemID,
+		Description: description,
+		Owner:       clientID,
+		OwnerOrg:    clientOrgID,
+	}
+
+	return putItem(ctx, &item)
+}
+
+// ReadItem allows all members of the channel to read an item of the registry.
+func (c *AuctionContract) ReadItem(ctx contractapi.TransactionContextInterface, itemID string) (*Item, error) {
+	return getItem(ctx, itemID)
+}
+
+// getItem is an internal utility function to get an item from public state.
+func getItem(ctx contractapi.TransactionContextInterface, itemID string) (*Item, error) {
+	itemKey, err := ctx.GetStub().CreateCompositeKey(itemKeyType, []string{itemID})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	bytes, err := ctx.GetStub().GetState(itemKey)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get item %v: %v", itemID, err)
+	}
+	if bytes == nil {
+		return nil, fmt.Errorf("Item %v is not registered", itemID)
+	}
+
+	item := new(Item)
+
+	err = json.Unmarshal(bytes, item)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal item %v: %v", itemID, err)
+	}
+
+	return item, nil
+}
+
+// putItem is an internal utility function to put an item into public state, and
+// set the organization of its owner as the endorser of the item.
+func putItem(ctx contractapi.TransactionContextInterface, item *Item) error {
+	itemKey, err := ctx.GetStub().CreateCompositeKey(itemKeyType, []string{item.ID})
+	if err != nil {
+		return fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	bytes, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal item %v: %v", item.ID, err)
+	}
+
+	err = ctx.GetStub().PutState(itemKey, bytes)
+	if err != nil {
+		return fmt.Errorf("Failed to put item %v in public data: %v", item.ID, err)
+	}
+
+	err = setAssetStateBasedEndorsement(ctx, itemKey, item.OwnerOrg)
+	if err != nil {
+		return fmt.Errorf("Failed setting state based endorsement for item: %v", err)
+	}
+
+	return nil
+}
+
+// lockItem is an internal utility function to lock an item of the seller for an
+// auction, so that it cannot be sold twice.
+func lockItem(ctx contractapi.TransactionContextInterface, itemID string, seller string, auctionID string) error {
+	item, err := getItem(ctx, itemID)
+	if err != nil {
+		return err
+	}
+
+	if item.Owner != seller {
+		return fmt.Errorf("Permission denied, client id %v is not the owner of item %v", seller, itemID)
+	}
+	if item.AuctionID != "" {
+		return fmt.Errorf("Item %v is already sold by auction %v", itemID, item.AuctionID)
+	}
+
+	item.AuctionID = auctionID
+
+	return putItem(ctx, item)
+}
+
+// releaseItem is an internal utility function to unlock the item of an auction
+// that ended or was cancelled. If the auction was sold, the item is transferred
+// to the winner and their organization. Double auctions do not lock an item.
+func releaseItem(ctx contractapi.TransactionContextInterface, auction *Auction, winnerOrg string) error {
+	if auction.Format == doubleFormat {
+		return nil
+	}
+
+	item, err := getItem(ctx, auction.ItemSold)
+	if err != nil {
+		return err
+	}
+
+	if item.AuctionID != auction.ID {
+		return fmt.Errorf("Item %v is not locked by auction %v", item.ID, auction.ID)
+	}
+
+	item.AuctionID = ""
+	if auction.Winner != "" {
+		item.Owner = auction.Winner
+		item.OwnerOrg = winnerOrg
+	}
+
+	return putItem(ctx, item)
+}
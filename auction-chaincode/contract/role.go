@@ -0,0 +1,117 @@
+package contract
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// roleAttribute is the X.509 attribute that holds the roles of a client
+// identity, issued by the CA when the identity is registered. An identity with
+// several roles lists them separated by commas, such as "seller,bidder".
+const roleAttribute = "auction.role"
+
+// Roles of the client identities. Sellers create auctions and bidders bid on
+// them. Auctioneers can close and end the auctions of the sellers of their
+// organization.
+const (
+	sellerRole     = "seller"
+	bidderRole     = "bidder"
+	auctioneerRole = "auctioneer"
+)
+
+// transactionRoles are the roles that can submit the transactions that need
+// one. The traders of a double auction submit their buy and sell orders as
+// bidders, and its market can be run by an auctioneer. Bids already submitted
+// can be revealed and withdrawn without a role, and the other transactions can
+// be submitted by any client identity.
+var transactionRoles = map[string][]string{
+	"CreateAuction":        {sellerRole},
+	"CreateDutchAuction":   {sellerRole},
+	"CreateEnglishAuction": {sellerRole},
+	"CreateDoubleAuction":  {sellerRole, auctioneerRole},
+	"CreateBid":            {bidderRole},
+	"SubmitBid":            {bidderRole},
+	"PlaceOpenBid":         {bidderRole},
+	"AcceptPrice":          {bidderRole},
+}
+
+// GetBeforeTransaction returns the function called by the contract API before
+// every transaction of the contract, which checks the role of the client.
+func (c *AuctionContract) GetBeforeTransaction() interface{} {
+	return checkRole
+}
+
+// checkRole is an internal utility function that checks that the submitting
+// client has one of the roles needed by the transaction being invoked.
+func checkRole(ctx contractapi.TransactionContextInterface) error {
+	// The function name can be prefixed with the name of the contract, and the
+	// contract API accepts it with a lower case first letter.
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	function = function[strings.LastIndex(function, ":")+1:]
+	if function != "" {
+		runes := []rune(function)
+		runes[0] = unicode.ToUpper(runes[0])
+		function = string(runes)
+	}
+
+	roles, ok := transactionRoles[function]
+	if !ok {
+		return nil
+	}
+
+	for _, role := range roles {
+		allowed, err := hasRole(ctx, role)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Permission denied, %v requires the %v role", function, strings.Join(roles, " or "))
+}
+
+// hasRole is an internal utility function that checks if the submitting client
+// has a role in the role attribute of its identity.
+func hasRole(ctx contractapi.TransactionContextInterface, role string) (bool, error) {
+	value, found, err := ctx.GetClientIdentity().GetAttributeValue(roleAttribute)
+	if err != nil {
+		return false, fmt.Errorf("Failed to get %v attribute of client identity: %v", roleAttribute, err)
+	}
+	if !found {
+		return false, nil
+	}
+
+	for _, clientRole := range strings.Split(value, ",") {
+		if strings.TrimSpace(clientRole) == role {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// actsForSeller is an internal utility function that checks if the submitting
+// client is the seller of an auction, or an auctioneer of the organization of
+// the seller.
+func actsForSeller(ctx contractapi.TransactionContextInterface, auction *Auction, clientID string) (bool, error) {
+	if clientID == auction.Seller {
+		return true, nil
+	}
+
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return false, fmt.Errorf("Failed to get client identity MSP ID: %v", err)
+	}
+
+	// The organization of the seller is the first organization of the auction.
+	if len(auction.Orgs) == 0 || auction.Orgs[0] != clientOrgID {
+		return false, nil
+	}
+
+	return hasRole(ctx, auctioneerRole)
+}
@@ -0,0 +1,216 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// getAuction is an internal utility function to get the auction document from
+// public state, without its private and revealed bids.
+func getAuction(ctx contractapi.TransactionContextInterface, auctionID string) (*Auction, error) {
+	// Get Auction from the ledger.
+	bytes, err := ctx.GetStub().GetState(auctionID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get auction object %v: %v", auctionID, err)
+	}
+	if bytes == nil {
+		return nil, fmt.Errorf("Auction %v does not exist", auctionID)
+	}
+
+	auction := new(Auction)
+
+	err = json.Unmarshal(bytes, auction)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal auction object %v: %v", auctionID, err)
+	}
+	auction.ID = auctionID
+
+	return auction, nil
+}
+
+// putAuction is an internal utility function to put the auction document into
+// public state. Private and revealed bids are stored under their own keys, and
+// are never written to the auction document.
+func putAuction(ctx contractapi.TransactionContextInterface, auctionID string, auction *Auction) error {
+	document := *auction
+	document.PrivateBids = nil
+	document.RevealedBids = nil
+
+	bytes, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal auction object %v: %v", auctionID, err)
+	}
+
+	err = ctx.GetStub().PutState(auctionID, bytes)
+	if err != nil {
+		return fmt.Errorf("Failed to put auction %v in public data: %v", auctionID, err)
+	}
+
+	return nil
+}
+
+// putAuctionIndexes is an internal utility function to add a new auction to the
+// status, seller and item indexes.
+func putAuctionIndexes(ctx contractapi.TransactionContextInterface, auctionID string, auction *Auction) error {
+	indexes := map[string]string{
+		statusIndexType: auction.Status,
+		sellerIndexType: auction.Seller,
+		itemIndexType:   auction.ItemSold,
+	}
+
+	for indexType, value := range indexes {
+		err := putIndexKey(ctx, indexType, value, auctionID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateAuctionStatusIndex is an internal utility function to move an auction
+// from the index of its old status to the index of its new status.
+func updateAuctionStatusIndex(ctx contractapi.TransactionContextInterface, auctionID string, oldStatus string, newStatus string) error {
+	oldKey, err := ctx.GetStub().CreateCompositeKey(statusIndexType, []string{oldStatus, auctionID})
+	if err != nil {
+		return fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	err = ctx.GetStub().DelState(oldKey)
+	if err != nil {
+		return fmt.Errorf("Failed to delete index key %v: %v", oldKey, err)
+	}
+
+	return putIndexKey(ctx, statusIndexType, newStatus, auctionID)
+}
+
+// putIndexKey is an internal utility function to put an index key. Only the key
+// is needed to look up the auction, the value is a single null byte.
+func putIndexKey(ctx contractapi.TransactionContextInterface, indexType string, value string, auctionID string) error {
+	indexKey, err := ctx.GetStub().CreateCompositeKey(indexType, []string{value, auctionID})
+	if err != nil {
+		return fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	err = ctx.GetStub().PutState(indexKey, []byte{0x00})
+	if err != nil {
+		return fmt.Errorf("Failed to put index key %v: %v", indexKey, err)
+	}
+
+	return nil
+}
+
+// getPrivateBid is an internal utility function to get the hash of a private bid
+// that was submitted to the auction. It returns nil if the bid was not submitted.
+func getPrivateBid(ctx contractapi.TransactionContextInterface, auctionID string, txID string) (*BidHash, error) {
+	privateBidKey, err := ctx.GetStub().CreateCompositeKey(privateBidKeyType, []string{auctionID, txID})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	bytes, err := ctx.GetStub().GetState(privateBidKey)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get private bid %v: %v", privateBidKey, err)
+	}
+	if bytes == nil {
+		return nil, nil
+	}
+
+	bidHash := new(BidHash)
+
+	err = json.Unmarshal(bytes, bidHash)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal private bid %v: %v", privateBidKey, err)
+	}
+
+	return bidHash, nil
+}
+
+// getPrivateBids is an internal utility function to get the hashes of all private
+// bids submitted to the auction, indexed by bid key.
+func getPrivateBids(ctx contractapi.TransactionContextInterface, auctionID string) (map[string]BidHash, error) {
+	privateBids := make(map[string]BidHash)
+
+	err := forEachAuctionBid(ctx, privateBidKeyType, auctionID, func(bidKey string, value []byte) error {
+		var bidHash BidHash
+
+		err := json.Unmarshal(value, &bidHash)
+		if err != nil {
+			return fmt.Errorf("Failed to unmarshal private bid %v: %v", bidKey, err)
+		}
+
+		privateBids[bidKey] = bidHash
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return privateBids, nil
+}
+
+// getRevealedBids is an internal utility function to get all bids revealed in the
+// auction, indexed by bid key.
+func getRevealedBids(ctx contractapi.TransactionContextInterface, auctionID string) (map[string]FullBid, error) {
+	revealedBids := make(map[string]FullBid)
+
+	err := forEachAuctionBid(ctx, revealedBidKeyType, auctionID, func(bidKey string, value []byte) error {
+		var bid FullBid
+
+		err := json.Unmarshal(value, &bid)
+		if err != nil {
+			return fmt.Errorf("Failed to unmarshal revealed bid %v: %v", bidKey, err)
+		}
+
+		revealedBids[bidKey] = bid
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return revealedBids, nil
+}
+
+// forEachAuctionBid is an internal utility function that walks every key of the
+// given object type stored for the auction, and calls visit with the bid key of
+// the private bid and the stored value.
+func forEachAuctionBid(ctx contractapi.TransactionContextInterface, objectType string, auctionID string, visit func(bidKey string, value []byte) error) error {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(objectType, []string{auctionID})
+	if err != nil {
+		return fmt.Errorf("Failed to get %v keys of auction %v: %v", objectType, auctionID, err)
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		response, err := iterator.Next()
+		if err != nil {
+			return fmt.Errorf("Failed to iterate %v keys of auction %v: %v", objectType, auctionID, err)
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil {
+			return fmt.Errorf("Failed to split composite key %v: %v", response.Key, err)
+		}
+		if len(attributes) != 2 {
+			return fmt.Errorf("Unexpected composite key %v", response.Key)
+		}
+
+		// The bids are indexed by the key of the bid in the private data collection.
+		bidKey, err := ctx.GetStub().CreateCompositeKey(bidKeyType, attributes)
+		if err != nil {
+			return fmt.Errorf("Failed to create composite key: %v", err)
+		}
+
+		err = visit(bidKey, response.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
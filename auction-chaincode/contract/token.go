@@ -0,0 +1,375 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TokenContract is the contract of the fungible tokens used to pay for auctions.
+// Every client identity has an account, keyed by the ID of the identity. Its
+// transactions are namespaced under TokenContractName, such as token:Transfer.
+type TokenContract struct {
+	contractapi.Contract
+}
+
+// TokenContractName is the name of the token contract in the chaincode.
+const TokenContractName = "token"
+
+// Account stores the tokens of a client identity that are not held for a bid.
+type Account struct {
+	Type    string `json:"objectType"`
+	Owner   string `json:"owner"`
+	Balance int    `json:"balance"`
+}
+
+// Hold stores the tokens of a bidder that are held for a bid until the auction
+// ends. The amount is the maximum price declared by the bidder, not the bid. The
+// deposit of the auction is held on top of it, and is forfeited to the seller if
+// the bid is not revealed before the reveal deadline.
+type Hold struct {
+	Type    string `json:"objectType"`
+	Holder  string `json:"holder"`
+	Amount  int    `json:"amount"`
+	Deposit int    `json:"deposit"`
+}
+
+// Object types of the keys that store the accounts, and the holds of the bids
+// of an auction, indexed by auction ID and transaction ID of the bid.
+const (
+	accountKeyType = "account"
+	holdKeyType    = "hold"
+)
+
+// minterMSPID is the organization whose clients can mint tokens.
+const minterMSPID = "Org1MSP"
+
+// Mint creates tokens in the account of the submitting client. Only clients of
+// the minter organization can mint tokens.
+func (c *TokenContract) Mint(ctx contractapi.TransactionContextInterface, amount int) error {
+	clientOrgID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("Failed to get client identity MSP ID: %v", err)
+	}
+	if clientOrgID != minterMSPID {
+		return fmt.Errorf("Permission denied, only clients of %v can mint tokens", minterMSPID)
+	}
+
+	if amount <= 0 {
+		return fmt.Errorf("Mint amount must be positive, got %v", amount)
+	}
+
+	clientID, err := getSubmittingClientID(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
+	return creditAccount(ctx, clientID, amount)
+}
+
+// Transfer moves tokens from the account of the submitting client to the account
+// of the recipient, given as the ID of a client identity.
+func (c *TokenContract) Transfer(ctx contractapi.TransactionContextInterface, recipient string, amount int) error {
+	if amount <= 0 {
+		return fmt.Errorf("Transfer amount must be positive, got %v", amount)
+	}
+
+	clientID, err := getSubmittingClientID(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
+	// Reads do not see the writes of the transaction, so the account would be
+	// credited with its balance before the debit.
+	if recipient == clientID {
+		return fmt.Errorf("Cannot transfer tokens to the same account")
+	}
+	if recipient == "" {
+		return fmt.Errorf("Recipient must not be empty")
+	}
+
+	err = debitAccount(ctx, clientID, amount)
+	if err != nil {
+		return err
+	}
+
+	return creditAccount(ctx, recipient, amount)
+}
+
+// BalanceOf returns the tokens of the account of a client identity that are not
+// held for a bid.
+func (c *TokenContract) BalanceOf(ctx contractapi.TransactionContextInterface, owner string) (int, error) {
+	account, err := getAccount(ctx, owner)
+	if err != nil {
+		return 0, err
+	}
+
+	return account.Balance, nil
+}
+
+// ClientAccountBalance returns the tokens of the account of the submitting client
+// that are not held for a bid.
+func (c *TokenContract) ClientAccountBalance(ctx contractapi.TransactionContextInterface) (int, error) {
+	clientID, err := getSubmittingClientID(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
+	return c.BalanceOf(ctx, clientID)
+}
+
+// ClientAccountID returns the ID of the account of the submitting client, which
+// other clients use to transfer tokens to the client.
+func (c *TokenContract) ClientAccountID(ctx contractapi.TransactionContextInterface) (string, error) {
+	clientID, err := getSubmittingClientID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get ID of the client identity: %v", err)
+	}
+
+	return clientID, nil
+}
+
+// getAccount is an internal utility function to get the account of a client
+// identity. Accounts that were never credited are empty.
+func getAccount(ctx contractapi.TransactionContextInterface, owner string) (*Account, error) {
+	accountKey, err := ctx.GetStub().CreateCompositeKey(accountKeyType, []string{owner})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	bytes, err := ctx.GetStub().GetState(accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get account %v: %v", owner, err)
+	}
+
+	account := &Account{Type: accountKeyType, Owner: owner}
+	if bytes == nil {
+		return account, nil
+	}
+
+	err = json.Unmarshal(bytes, account)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal account %v: %v", owner, err)
+	}
+
+	return account, nil
+}
+
+// putAccount is an internal utility function to put an account into public state.
+func putAccount(ctx contractapi.TransactionContextInterface, account *Account) error {
+	accountKey, err := ctx.GetStub().CreateCompositeKey(accountKeyType, []string{account.Owner})
+	if err != nil {
+		return fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	bytes, err := json.Marshal(account)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal account %v: %v", account.Owner, err)
+	}
+
+	err = ctx.GetStub().PutState(accountKey, bytes)
+	if err != nil {
+		return fmt.Errorf("Failed to put account %v in public data: %v", account.Owner, err)
+	}
+
+	return nil
+}
+
+// creditAccount is an internal utility function to add tokens to an account. An
+// account must be credited at most once per transaction, since reads do not see
+// the writes of the transaction.
+func creditAccount(ctx contractapi.TransactionContextInterface, owner string, amount int) error {
+	account, err := getAccount(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	balance := account.Balance + amount
+	if balance < account.Balance {
+		return fmt.Errorf("Balance of account %v would overflow", owner)
+	}
+	account.Balance = balance
+
+	return putAccount(ctx, account)
+}
+
+// debitAccount is an internal utility function to remove tokens from an account.
+func debitAccount(ctx contractapi.TransactionContextInterface, owner string, amount int) error {
+	account, err := getAccount(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	if account.Balance < amount {
+		return fmt.Errorf("Insufficient funds, account %v has %v tokens, %v needed", owner, account.Balance, amount)
+	}
+	account.Balance -= amount
+
+	return putAccount(ctx, account)
+}
+
+// placeHold is an internal utility function to move tokens of a bidder from
+// their account to a hold on their bid. Updates to the hold need to be endorsed
+// by the organizations of the auction.
+func placeHold(ctx contractapi.TransactionContextInterface, auctionID string, txID string, hold Hold, orgs []string) error {
+	total := hold.Amount + hold.Deposit
+	if total < hold.Amount {
+		return fmt.Errorf("Hold of %v tokens and deposit of %v tokens would overflow", hold.Amount, hold.Deposit)
+	}
+
+	err := debitAccount(ctx, hold.Holder, total)
+	if err != nil {
+		return err
+	}
+
+	holdKey, err := ctx.GetStub().CreateCompositeKey(holdKeyType, []string{auctionID, txID})
+	if err != nil {
+		return fmt.Errorf("Failed to create composite key: %v", err)
+	}
+
+	hold.Type = holdKeyType
+	holdBytes, _ := json.Marshal(hold)
+
+	err = ctx.GetStub().PutState(holdKey, holdBytes)
+	if err != nil {
+		return fmt.Errorf("Failed to put hold %v in public data: %v", holdKey, err)
+	}
+
+	err = setAssetStateBasedEndorsement(ctx, holdKey, orgs...)
+	if err != nil {
+		return fmt.Errorf("Failed setting state based endorsement for hold: %v", err)
+	}
+
+	return nil
+}
+
+// getHolds is an internal utility function to get the holds of the bids of an
+// auction, indexed by bid key.
+func getHolds(ctx contractapi.TransactionContextInterface, auctionID string) (map[string]Hold, error) {
+	holds := make(map[string]Hold)
+
+	err := forEachAuctionBid(ctx, holdKeyType, auctionID, func(bidKey string, value []byte) error {
+		var hold Hold
+
+		err := json.Unmarshal(value, &hold)
+		if err != nil {
+			return fmt.Errorf("Failed to unmarshal hold %v: %v", bidKey, err)
+		}
+
+		holds[bidKey] = hold
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return holds, nil
+}
+
+// forfeitedBids is an internal utility function to get the keys of the bids
+// whose deposits are forfeited, which are the bids that were not revealed once
+// the reveal deadline of the auction has passed.
+func forfeitedBids(ctx contractapi.TransactionContextInterface, auction *Auction, holds map[string]Hold) (map[string]bool, error) {
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+
+	forfeited := make(map[string]bool)
+	if now <= auction.RevealDeadline {
+		return forfeited, nil
+	}
+
+	for bidKey := range holds {
+		if _, revealed := auction.RevealedBids[bidKey]; !revealed {
+			forfeited[bidKey] = true
+		}
+	}
+
+	return forfeited, nil
+}
+
+// holdCoversBid is an internal utility function used to check that the tokens
+// held for a bid can pay the unit price of the bid for every unit it asks for.
+func holdCoversBid(hold Hold, price int, quantity int) bool {
+	return quantity > 0 && price <= hold.Amount/quantity
+}
+
+// payment is a number of tokens paid from the hold of a bid to a payee.
+type payment struct {
+	payee  string
+	amount int
+}
+
+// settleHolds is an internal utility function to release the holds of the bids of
+// an auction. The hold of every bid with an allocation pays the units allocated
+// to it to the seller, and the hold of every buy order of a double auction pays
+// the units it matched to their sellers. The rest of the hold is returned to its
+// bidder, so an auction without allocations or matches releases every hold. The
+// deposits of the forfeited bids are paid to the seller, other deposits are
+// returned.
+func settleHolds(ctx contractapi.TransactionContextInterface, auction *Auction, holds map[string]Hold, forfeited map[string]bool) error {
+	payments := make(map[string][]payment)
+	for _, allocation := range auction.Allocations {
+		payments[allocation.BidKey] = append(payments[allocation.BidKey], payment{payee: auction.Seller, amount: allocation.Quantity * allocation.Price})
+	}
+	for _, match := range auction.Matches {
+		payments[match.BidKey] = append(payments[match.BidKey], payment{payee: match.Seller, amount: match.Quantity * match.Price})
+	}
+
+	// Sum the credits of every account, since an account can only be credited
+	// once per transaction.
+	credits := make(map[string]int)
+	for bidKey, hold := range holds {
+		refund := hold.Amount
+		for _, payment := range payments[bidKey] {
+			credits[payment.payee] += payment.amount
+			refund -= payment.amount
+		}
+		credits[hold.Holder] += refund
+
+		if forfeited[bidKey] {
+			credits[auction.Seller] += hold.Deposit
+		} else {
+			credits[hold.Holder] += hold.Deposit
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(bidKey)
+		if err != nil {
+			return fmt.Errorf("Failed to split composite key %v: %v", bidKey, err)
+		}
+
+		holdKey, err := ctx.GetStub().CreateCompositeKey(holdKeyType, attributes)
+		if err != nil {
+			return fmt.Errorf("Failed to create composite key: %v", err)
+		}
+
+		err = ctx.GetStub().DelState(holdKey)
+		if err != nil {
+			return fmt.Errorf("Failed to delete hold %v: %v", holdKey, err)
+		}
+	}
+
+	// Credit the accounts in order, so that every peer returns the same error.
+	owners := []string{}
+	for owner := range credits {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+
+	for _, owner := range owners {
+		if credits[owner] == 0 {
+			continue
+		}
+
+		err := creditAccount(ctx, owner, credits[owner])
+		if err != nil {
+			return fmt.Errorf("Failed to settle hold: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -1,7 +1,10 @@
 package contract
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 
@@ -12,6 +15,12 @@ import (
 
 // GetSubmittingClientIdentity is an internal utility function to get submitting client identity.
 func (c *AuctionContract) GetSubmittingClientIdentity(ctx contractapi.TransactionContextInterface) (string, error) {
+	return getSubmittingClientID(ctx)
+}
+
+// getSubmittingClientID is an internal utility function to get the decoded ID of
+// the submitting client identity, shared by the contracts of the chaincode.
+func getSubmittingClientID(ctx contractapi.TransactionContextInterface) (string, error) {
 	// Get the MSP ID of submitting client identity.
 	b64ID, err := ctx.GetClientIdentity().GetID()
 	if err != nil {
@@ -27,16 +36,17 @@ func (c *AuctionContract) GetSubmittingClientIdentity(ctx contractapi.Transactio
 	return string(decodeID), nil
 }
 
-// setAssetStateBasedEndorsement sets the endorsement policy of a new auction.
-func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface, auctionID string, orgToEndorse string) error {
+// setAssetStateBasedEndorsement sets the endorsement policy of a new auction, or
+// of a key that has to be endorsed by the organizations of an auction.
+func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface, auctionID string, orgsToEndorse ...string) error {
 	// Get the endorsement policy.
 	endorsementPolicy, err := statebased.NewStateEP(nil)
 	if err != nil {
 		return fmt.Errorf("Failed to create endorsement policy: %v", err)
 	}
 
-	// Add the org to endorse to the policy.
-	err = endorsementPolicy.AddOrgs(statebased.RoleTypePeer, orgToEndorse)
+	// Add the orgs to endorse to the policy.
+	err = endorsementPolicy.AddOrgs(statebased.RoleTypePeer, orgsToEndorse...)
 	if err != nil {
 		return fmt.Errorf("Failed to add org to endorsement policy: %v", err)
 	}
@@ -56,6 +66,34 @@ func setAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface,
 	return nil
 }
 
+// addAuctionOrg is an internal utility function to add the organization of a
+// bidder to the organizations of an auction, whose endorsement is then needed
+// to update the auction. The caller puts the updated auction into state.
+func addAuctionOrg(ctx contractapi.TransactionContextInterface, auction *Auction, orgID string) error {
+	auction.Orgs = append(auction.Orgs, orgID)
+
+	err := addAssetStateBasedEndorsement(ctx, auction.ID, orgID)
+	if err != nil {
+		return fmt.Errorf("Failed setting state based endorsement for new organization: %v", err)
+	}
+
+	return nil
+}
+
+// bidEndorsers is an internal utility function that returns the organizations
+// that endorse the updates of a submitted bid and of its hold: the organization
+// of the seller and the organization of the bidder. Neither leaves the auction
+// while the bid exists, so the endorsement policy of the bid is set once, when
+// the bid is submitted, and does not change when other organizations join or
+// leave the auction.
+func bidEndorsers(auction *Auction, bidderOrg string) []string {
+	if len(auction.Orgs) == 0 || auction.Orgs[0] == bidderOrg {
+		return []string{bidderOrg}
+	}
+
+	return []string{auction.Orgs[0], bidderOrg}
+}
+
 // addAssetStateBasedEndorsement adds a new organization as an endorser of the auction
 func addAssetStateBasedEndorsement(ctx contractapi.TransactionContextInterface, auctionID string, orgToEndorse string) error {
 	// Get the endorsement policy.
@@ -128,6 +166,63 @@ func verifyClientOrgMatchesPeerOrg(ctx contractapi.TransactionContextInterface)
 	return nil
 }
 
+// getTxTimestamp is an internal utility function to get the transaction timestamp
+// in seconds since the Unix epoch. The timestamp is set by the client and is the
+// same on every endorsing peer.
+func getTxTimestamp(ctx contractapi.TransactionContextInterface) (int64, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return 0, fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+
+	return timestamp.GetSeconds(), nil
+}
+
+// parseTransientBid is an internal utility function used to parse a bid passed in
+// the transient map. Bids with unknown fields or of another object type are rejected.
+func parseTransientBid(transientBid []byte) (*FullBid, error) {
+	decoder := json.NewDecoder(bytes.NewReader(transientBid))
+	decoder.DisallowUnknownFields()
+
+	var bid FullBid
+
+	err := decoder.Decode(&bid)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal bid: %v", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("Unexpected data after bid")
+	}
+	if bid.Type != bidKeyType {
+		return nil, fmt.Errorf("Bid object type must be %v, got %v", bidKeyType, bid.Type)
+	}
+
+	return &bid, nil
+}
+
+// validateSalt is an internal utility function used to verify that the salt of a
+// bid is hex encoded and long enough to prevent brute-forcing the bid hash.
+func validateSalt(salt string) error {
+	if salt == "" {
+		return fmt.Errorf("Salt is missing")
+	}
+
+	saltBytes, err := hex.DecodeString(salt)
+	if err != nil {
+		return fmt.Errorf("Salt must be hex encoded: %v", err)
+	}
+	if len(saltBytes) < minSaltLength {
+		return fmt.Errorf("Salt must contain at least %v bytes, got %v", minSaltLength, len(saltBytes))
+	}
+
+	// Reject salts made of a single repeated byte, which carry no entropy.
+	if bytes.Count(saltBytes, saltBytes[:1]) == len(saltBytes) {
+		return fmt.Errorf("Salt must be random")
+	}
+
+	return nil
+}
+
 // contains returns true if the string is in the slice, otherwise false
 func contains(s []string, str string) bool {
 	for _, a := range s {
@@ -140,8 +235,49 @@ func contains(s []string, str string) bool {
 }
 
 // checkForHigherBid is an internal function that is used to determine if a
-// winning bid has yet to be revealed.
-func checkForHigherBid(ctx contractapi.TransactionContextInterface, auctionPrice int, revealedBidders map[string]FullBid, bidders map[string]BidHash) error {
+// bid that has yet to be revealed would change the winner or the clearing
+// price of the auction. A bid whose unit price is above the winning bid would
+// win units, and one above the clearing price would change the clearing price.
+// A bid at the winning bid would also win units if the tie-break rule puts it
+// before the marginal bid, the last bid that won units.
+// Only bids within the tokens held for them are checked.
+// Once the reveal deadline has passed, bids that were not revealed are forfeited
+// and are no longer checked.
+func checkForHigherBid(ctx contractapi.TransactionContextInterface, winningBid int, clearingPrice int, marginalBidKey string, revealedBidders map[string]FullBid, bidders map[string]BidHash, holds map[string]Hold, revealDeadline int64) error {
+	marginalBid, hasMarginalBid := revealedBidders[marginalBidKey]
+
+	return checkUnrevealedBids(ctx, revealedBidders, bidders, revealDeadline, func(bidKey string, bid *FullBid) error {
+		// Check if bid is higher than the winning bid, or would set a higher
+		// clearing price. A bid above the tokens held for it can never be
+		// revealed, so it is ignored.
+		if !holdCoversBid(holds[bidKey], bid.Price, bid.Quantity) {
+			return nil
+		} else if bid.Price > winningBid {
+			return fmt.Errorf("Cannot close auction, bidder has a higher price: %v", bidKey)
+		} else if bid.Price == winningBid && hasMarginalBid && marginalBid.Price == winningBid && bidComesFirst(bidders, bidKey, marginalBidKey) {
+			return fmt.Errorf("Cannot close auction, bidder has the same price and was submitted first: %v", bidKey)
+		} else if bid.Price > clearingPrice {
+			return fmt.Errorf("Cannot close auction, bidder would change the clearing price: %v", bidKey)
+		}
+
+		return nil
+	})
+}
+
+// checkUnrevealedBids is an internal function that passes the bids of the
+// organization of the peer that have yet to be revealed to a check, and returns
+// an error if the check fails for any of them. The bids of other organizations
+// are only checked to exist. Once the reveal deadline has passed, bids that were
+// not revealed are forfeited and are no longer checked.
+func checkUnrevealedBids(ctx contractapi.TransactionContextInterface, revealedBidders map[string]FullBid, bidders map[string]BidHash, revealDeadline int64, check func(bidKey string, bid *FullBid) error) error {
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get transaction timestamp: %v", err)
+	}
+	if now > revealDeadline {
+		return nil
+	}
+
 	// Get MSP ID of peer org.
 	peerMSPID, err := shim.GetMSPID()
 	if err != nil {
@@ -150,15 +286,15 @@ func checkForHigherBid(ctx contractapi.TransactionContextInterface, auctionPrice
 
 	var error error = nil
 
-	// Loop through all bidders and check if they are the highest bidder.
+	// Loop through all bidders and check their bids.
 	for bidKey, privateBid := range bidders {
 		_, bidInAuction := revealedBidders[bidKey]
 
-		// Bid is not already revealed, so check if it is the highest bidder, otherwise skip.
+		// Bid is not already revealed, so check it, otherwise skip.
 		if !bidInAuction {
 			collection := "_implicit_org_" + privateBid.Org
 
-			// If private bid is from the same org as the peer, then check if it is the highest bidder.
+			// If private bid is from the same org as the peer, then check it.
 			if privateBid.Org == peerMSPID {
 				// Get bid from private data collection.
 				bytes, err := ctx.GetStub().GetPrivateData(collection, bidKey)
@@ -177,9 +313,9 @@ func checkForHigherBid(ctx contractapi.TransactionContextInterface, auctionPrice
 					return fmt.Errorf("Failed to unmarshal bid %v: %v", bidKey, err)
 				}
 
-				// Check if bid is higher than auction price.
-				if bid.Price > auctionPrice {
-					error = fmt.Errorf("Cannot close auction, bidder has a higher price: %v", err)
+				checkErr := check(bidKey, bid)
+				if checkErr != nil {
+					error = checkErr
 				}
 			} else {
 				// Get bid hash from from private data collection.
@@ -196,3 +332,53 @@ func checkForHigherBid(ctx contractapi.TransactionContextInterface, auctionPrice
 
 	return error
 }
+
+// reserveRevealPeriod is the time, in seconds, that the seller has after the
+// reveal deadline to reveal the reserve of an auction.
+const reserveRevealPeriod = 24 * 60 * 60
+
+// revealReserve is an internal function that returns the reserve price revealed
+// by the seller in the transient map. The revealed reserve must match the hash
+// committed when the auction was created. Auctions without a reserve return 0.
+// Once the reserve reveal period has passed, the auction can be ended without
+// the reserve, which no bid meets, so that a seller who withholds the reserve
+// cannot keep the tokens of the bidders held.
+func revealReserve(ctx contractapi.TransactionContextInterface, auction *Auction, now int64) (int, error) {
+	if auction.ReserveHash == "" {
+		return 0, nil
+	}
+
+	// Get the reserve from the transient map.
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return 0, fmt.Errorf("Error getting reserve from transient map: %v", err)
+	}
+
+	transientReserve, ok := transientMap["reserve"]
+	if !ok && now > auction.RevealDeadline+reserveRevealPeriod {
+		return maxInt, nil
+	}
+	if !ok {
+		return 0, fmt.Errorf("Reserve key not found in the transient map")
+	}
+
+	// Check that the hash of the revealed reserve matches the hash on the auction.
+	hash := sha256.Sum256(transientReserve)
+	calculatedReserveHash := fmt.Sprintf("%x", hash)
+	if calculatedReserveHash != auction.ReserveHash {
+		return 0, fmt.Errorf("Hash %s for reserve %s does not match hash in auction: %s",
+			calculatedReserveHash,
+			transientReserve,
+			auction.ReserveHash,
+		)
+	}
+
+	var reserve Reserve
+
+	err = json.Unmarshal(transientReserve, &reserve)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to unmarshal reserve: %v", err)
+	}
+
+	return reserve.Price, nil
+}
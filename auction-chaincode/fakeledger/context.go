@@ -0,0 +1,44 @@
+package fakeledger
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Context implements contractapi.TransactionContextInterface for a simulated
+// transaction.
+type Context struct {
+	stub   *Stub
+	client *Identity
+}
+
+// GetStub returns the stub of the transaction.
+func (c *Context) GetStub() shim.ChaincodeStubInterface {
+	return c.stub
+}
+
+// GetClientIdentity returns the identity that submits the transaction.
+func (c *Context) GetClientIdentity() cid.ClientIdentity {
+	if c.client == nil {
+		return nil
+	}
+
+	return &clientIdentity{identity: c.client}
+}
+
+// Stub returns the stub of the transaction, to inspect its read and write sets.
+func (c *Context) Stub() *Stub {
+	return c.stub
+}
+
+// Run runs fn with the context on the peer of the transaction.
+func (c *Context) Run(fn func(ctx contractapi.TransactionContextInterface) error) error {
+	return withPeerMSPID(c.stub.peerMSPID, func() error {
+		return fn(c)
+	})
+}
+
+var _ contractapi.TransactionContextInterface = (*Context)(nil)
+var _ shim.ChaincodeStubInterface = (*Stub)(nil)
+var _ cid.ClientIdentity = (*clientIdentity)(nil)
@@ -0,0 +1,265 @@
+package fakeledger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+// Policy decides if a transaction endorsed by the peers of the given
+// organizations satisfies an endorsement policy.
+type Policy func(endorsers []string) bool
+
+// AnyOf returns a policy satisfied by an endorsement of any of the organizations.
+func AnyOf(orgs ...string) Policy {
+	return func(endorsers []string) bool {
+		for _, org := range orgs {
+			if containsOrg(endorsers, org) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// AllOf returns a policy satisfied by endorsements of all the organizations.
+func AllOf(orgs ...string) Policy {
+	return func(endorsers []string) bool {
+		for _, org := range orgs {
+			if !containsOrg(endorsers, org) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// Endorsement is the result of the simulation of a transaction on the peer of
+// an organization.
+type Endorsement struct {
+	PeerMSPID string
+	Context   *Context
+	Err       error
+}
+
+// Proposal is a transaction simulated on the peers of several organizations.
+type Proposal struct {
+	Endorsements []*Endorsement
+}
+
+// Endorse simulates the same transaction once on the peer of each organization,
+// as a client gathering endorsements would. Every simulation has the private
+// data view of its peer, and shim.GetMSPID returns the MSP ID of its peer.
+func (l *Ledger) Endorse(tx Transaction, peerMSPIDs []string, fn func(ctx contractapi.TransactionContextInterface) error) *Proposal {
+	if tx.TxID == "" {
+		l.txCount++
+		tx.TxID = fmt.Sprintf("tx%d", l.txCount)
+	}
+
+	proposal := &Proposal{}
+	for _, peerMSPID := range peerMSPIDs {
+		tx.PeerMSPID = peerMSPID
+
+		ctx, err := l.Execute(tx, fn)
+		proposal.Endorsements = append(proposal.Endorsements, &Endorsement{PeerMSPID: peerMSPID, Context: ctx, Err: err})
+	}
+
+	return proposal
+}
+
+// Endorsers returns the organizations whose peers endorsed the transaction.
+func (p *Proposal) Endorsers() []string {
+	endorsers := []string{}
+	for _, endorsement := range p.Endorsements {
+		if endorsement.Err == nil {
+			endorsers = append(endorsers, endorsement.PeerMSPID)
+		}
+	}
+
+	return endorsers
+}
+
+// SubmitEndorsed simulates a transaction on the peers of the organizations and
+// commits it if the endorsements are valid.
+func (l *Ledger) SubmitEndorsed(tx Transaction, peerMSPIDs []string, fn func(ctx contractapi.TransactionContextInterface) error) error {
+	return l.CommitProposal(l.Endorse(tx, peerMSPIDs, fn))
+}
+
+// CommitProposal validates the endorsements of a proposal and commits it. Like a
+// client, the endorsements of peers that failed the simulation are left out, and
+// the other peers must agree on the read and write sets. The endorsing
+// organizations must satisfy the state validation parameter of every key written
+// by the transaction, or the chaincode policy of the ledger for keys without one.
+func (l *Ledger) CommitProposal(p *Proposal) error {
+	var endorsed *Endorsement
+	var rwset []byte
+	for _, endorsement := range p.Endorsements {
+		if endorsement.Err != nil {
+			continue
+		}
+
+		digest, err := readWriteSet(endorsement.Context.stub)
+		if err != nil {
+			return err
+		}
+
+		if endorsed == nil {
+			endorsed = endorsement
+			rwset = digest
+		} else if !bytes.Equal(rwset, digest) {
+			return fmt.Errorf("read and write sets of peers of %v and %v do not match", endorsed.PeerMSPID, endorsement.PeerMSPID)
+		}
+	}
+	if endorsed == nil {
+		if len(p.Endorsements) == 0 {
+			return fmt.Errorf("transaction was not sent to any peer")
+		}
+
+		return fmt.Errorf("transaction was not endorsed: %v", p.Endorsements[0].Err)
+	}
+
+	err := l.validateEndorsements(endorsed.Context.stub, p.Endorsers())
+	if err != nil {
+		return err
+	}
+
+	return l.Commit(endorsed.Context)
+}
+
+// validateEndorsements checks that the endorsers satisfy the endorsement
+// policies of the keys written by the transaction.
+func (l *Ledger) validateEndorsements(stub *Stub, endorsers []string) error {
+	keys := []string{}
+	for key := range stub.writes {
+		keys = append(keys, key)
+	}
+	for key := range stub.metadata {
+		if _, ok := stub.writes[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		ep, ok := l.validation[key]
+		if !ok {
+			if l.ChaincodePolicy != nil && !l.ChaincodePolicy(endorsers) {
+				return fmt.Errorf("endorsement policy failure on key %v: chaincode policy not satisfied by %v", key, endorsers)
+			}
+			continue
+		}
+
+		satisfied, err := evaluateValidationParameter(ep, endorsers)
+		if err != nil {
+			return fmt.Errorf("invalid state validation parameter of key %v: %v", key, err)
+		}
+		if !satisfied {
+			return fmt.Errorf("endorsement policy failure on key %v: state validation parameter not satisfied by %v", key, endorsers)
+		}
+	}
+
+	return nil
+}
+
+// evaluateValidationParameter returns true if peers of the endorsing
+// organizations satisfy the signature policy of a state validation parameter.
+func evaluateValidationParameter(ep []byte, endorsers []string) (bool, error) {
+	envelope := &common.SignaturePolicyEnvelope{}
+
+	err := proto.Unmarshal(ep, envelope)
+	if err != nil {
+		return false, err
+	}
+
+	return evaluateSignaturePolicy(envelope.Rule, envelope.Identities, endorsers)
+}
+
+// evaluateSignaturePolicy evaluates a rule of a signature policy, where every
+// principal is the peer role of an organization.
+func evaluateSignaturePolicy(rule *common.SignaturePolicy, identities []*msp.MSPPrincipal, endorsers []string) (bool, error) {
+	switch policy := rule.Type.(type) {
+	case *common.SignaturePolicy_SignedBy:
+		if policy.SignedBy < 0 || int(policy.SignedBy) >= len(identities) {
+			return false, fmt.Errorf("principal %v does not exist", policy.SignedBy)
+		}
+
+		role := &msp.MSPRole{}
+
+		err := proto.Unmarshal(identities[policy.SignedBy].Principal, role)
+		if err != nil {
+			return false, err
+		}
+		if role.Role != msp.MSPRole_PEER {
+			return false, fmt.Errorf("unsupported role %v", role.Role)
+		}
+
+		return containsOrg(endorsers, role.MspIdentifier), nil
+	case *common.SignaturePolicy_NOutOf_:
+		satisfied := int32(0)
+		for _, child := range policy.NOutOf.Rules {
+			ok, err := evaluateSignaturePolicy(child, identities, endorsers)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				satisfied++
+			}
+		}
+
+		return satisfied >= policy.NOutOf.N, nil
+	}
+
+	return false, fmt.Errorf("unsupported signature policy %T", rule.Type)
+}
+
+// readWriteSet returns a canonical encoding of the read and write sets of a
+// simulated transaction. Private data writes are compared by hash, as they are
+// in the proposal responses of the peers.
+func readWriteSet(stub *Stub) ([]byte, error) {
+	type hashedWrite struct {
+		Hash     []byte
+		IsDelete bool
+	}
+
+	private := make(map[string]map[string]hashedWrite)
+	for collection, writes := range stub.private {
+		private[collection] = make(map[string]hashedWrite)
+		for key, write := range writes {
+			hash := sha256.Sum256(write.Value)
+			private[collection][key] = hashedWrite{Hash: hash[:], IsDelete: write.IsDelete}
+		}
+	}
+
+	rwset, err := json.Marshal(struct {
+		Reads    map[string]uint64
+		Writes   map[string]Write
+		Metadata map[string][]byte
+		Private  map[string]map[string]hashedWrite
+		Event    *Event
+	}{stub.reads, stub.writes, stub.metadata, private, stub.event})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal read and write sets: %v", err)
+	}
+
+	return rwset, nil
+}
+
+// containsOrg returns true if the organization is in the list.
+func containsOrg(orgs []string, org string) bool {
+	for _, o := range orgs {
+		if o == org {
+			return true
+		}
+	}
+
+	return false
+}
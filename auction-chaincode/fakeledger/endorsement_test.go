@@ -0,0 +1,71 @@
+package fakeledger
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitProposalRejectsDifferentReadWriteSets(t *testing.T) {
+	ledger := New()
+	client := NewIdentity("Org1MSP", "alice")
+
+	err := ledger.SubmitEndorsed(Transaction{Client: client}, []string{"Org1MSP", "Org2MSP"}, func(ctx contractapi.TransactionContextInterface) error {
+		peerMSPID, err := shim.GetMSPID()
+		if err != nil {
+			return err
+		}
+
+		return ctx.GetStub().PutState("key", []byte(peerMSPID))
+	})
+	require.EqualError(t, err, "read and write sets of peers of Org1MSP and Org2MSP do not match")
+	require.Nil(t, ledger.GetState("key"))
+}
+
+func TestCommitProposalEvaluatesStateValidationParameters(t *testing.T) {
+	ledger := New()
+	ledger.ChaincodePolicy = AnyOf("Org1MSP", "Org2MSP")
+	client := NewIdentity("Org1MSP", "alice")
+
+	err := ledger.SubmitEndorsed(Transaction{Client: client}, []string{"Org1MSP"}, func(ctx contractapi.TransactionContextInterface) error {
+		policy, err := statebased.NewStateEP(nil)
+		if err != nil {
+			return err
+		}
+		err = policy.AddOrgs(statebased.RoleTypePeer, "Org1MSP", "Org2MSP")
+		if err != nil {
+			return err
+		}
+		ep, err := policy.Policy()
+		if err != nil {
+			return err
+		}
+
+		err = ctx.GetStub().PutState("key", []byte("value"))
+		if err != nil {
+			return err
+		}
+
+		return ctx.GetStub().SetStateValidationParameter("key", ep)
+	})
+	require.NoError(t, err)
+
+	update := func(ctx contractapi.TransactionContextInterface) error {
+		return ctx.GetStub().PutState("key", []byte("update"))
+	}
+
+	err = ledger.SubmitEndorsed(Transaction{Client: client}, []string{"Org1MSP"}, update)
+	require.EqualError(t, err, "endorsement policy failure on key key: state validation parameter not satisfied by [Org1MSP]")
+
+	err = ledger.SubmitEndorsed(Transaction{Client: client}, []string{"Org1MSP", "Org2MSP"}, update)
+	require.NoError(t, err)
+	require.Equal(t, []byte("update"), ledger.GetState("key"))
+
+	err = ledger.SubmitEndorsed(Transaction{Client: client}, []string{"Org3MSP"}, func(ctx contractapi.TransactionContextInterface) error {
+		return ctx.GetStub().PutState("other", []byte("value"))
+	})
+	require.EqualError(t, err, "endorsement policy failure on key other: chaincode policy not satisfied by [Org3MSP]")
+}
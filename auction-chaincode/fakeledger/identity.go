@@ -0,0 +1,77 @@
+package fakeledger
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// Identity is a client identity of an organization.
+type Identity struct {
+	MSPID      string
+	Name       string
+	Attributes map[string]string
+}
+
+// NewIdentity returns the client identity with the given name in the given
+// organization. Attributes are given as name and value pairs.
+func NewIdentity(mspID string, name string, attributes ...string) *Identity {
+	identity := &Identity{
+		MSPID:      mspID,
+		Name:       name,
+		Attributes: make(map[string]string),
+	}
+
+	for i := 0; i+1 < len(attributes); i += 2 {
+		identity.Attributes[attributes[i]] = attributes[i+1]
+	}
+
+	return identity
+}
+
+// ID returns the decoded ID of the identity, in the format used for X.509
+// identities by the client identity library.
+func (i *Identity) ID() string {
+	return fmt.Sprintf("x509::CN=%s,OU=client::CN=ca.%s", i.Name, strings.ToLower(i.MSPID))
+}
+
+// clientIdentity implements cid.ClientIdentity for an Identity.
+type clientIdentity struct {
+	identity *Identity
+}
+
+// GetID returns the base64 encoded ID of the identity.
+func (ci *clientIdentity) GetID() (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(ci.identity.ID())), nil
+}
+
+// GetMSPID returns the MSP ID of the identity.
+func (ci *clientIdentity) GetMSPID() (string, error) {
+	return ci.identity.MSPID, nil
+}
+
+// GetAttributeValue returns the value of an attribute of the identity.
+func (ci *clientIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	value, found := ci.identity.Attributes[attrName]
+
+	return value, found, nil
+}
+
+// AssertAttributeValue checks that the identity has the attribute with the value.
+func (ci *clientIdentity) AssertAttributeValue(attrName string, attrValue string) error {
+	value, found, _ := ci.GetAttributeValue(attrName)
+	if !found {
+		return fmt.Errorf("Attribute '%s' was not found", attrName)
+	}
+	if value != attrValue {
+		return fmt.Errorf("Attribute '%s' equals '%s', not '%s'", attrName, value, attrValue)
+	}
+
+	return nil
+}
+
+// GetX509Certificate is not supported, the identity has no certificate.
+func (ci *clientIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return nil, fmt.Errorf("Identity %v has no certificate", ci.identity.Name)
+}
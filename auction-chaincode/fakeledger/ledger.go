@@ -0,0 +1,264 @@
+// Package fakeledger provides an in-memory ledger to unit test chaincode. Each
+// transaction is simulated with a Stub that implements
+// shim.ChaincodeStubInterface, and a Context that implements
+// contractapi.TransactionContextInterface. Like a Fabric peer, a stub reads the
+// committed state and buffers its writes, which are only applied to the ledger
+// when the transaction is committed.
+package fakeledger
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// ChannelID is the channel returned by the stubs of the ledger.
+const ChannelID = "mychannel"
+
+// implicitCollectionPrefix is the prefix of the name of the implicit data
+// collection of an organization.
+const implicitCollectionPrefix = "_implicit_org_"
+
+// peerMSPIDEnv is the environment variable read by shim.GetMSPID.
+const peerMSPIDEnv = "CORE_PEER_LOCALMSPID"
+
+// Ledger stores the committed public state, private data, state validation
+// parameters and history of the channel.
+type Ledger struct {
+	// Clock is the timestamp of the next transaction.
+	Clock time.Time
+	// ChaincodePolicy is the endorsement policy of keys without a state
+	// validation parameter. Any endorsement is accepted if it is nil.
+	ChaincodePolicy Policy
+
+	state             map[string][]byte
+	versions          map[string]uint64
+	validation        map[string][]byte
+	private           map[string]map[string][]byte
+	privateValidation map[string]map[string][]byte
+	history           map[string][]*queryresult.KeyModification
+	txCount           int
+	blockNumber       uint64
+}
+
+// New returns an empty ledger.
+func New() *Ledger {
+	return &Ledger{
+		Clock:             time.Unix(1600000000, 0),
+		state:             make(map[string][]byte),
+		versions:          make(map[string]uint64),
+		validation:        make(map[string][]byte),
+		private:           make(map[string]map[string][]byte),
+		privateValidation: make(map[string]map[string][]byte),
+		history:           make(map[string][]*queryresult.KeyModification),
+	}
+}
+
+// Advance moves the clock of the ledger forward.
+func (l *Ledger) Advance(d time.Duration) {
+	l.Clock = l.Clock.Add(d)
+}
+
+// Transaction describes a transaction proposal.
+type Transaction struct {
+	// Client is the identity that submits the transaction.
+	Client *Identity
+	// PeerMSPID is the MSP ID of the peer that simulates the transaction. It
+	// defaults to the MSP ID of the client.
+	PeerMSPID string
+	// Transient is the transient map of the proposal.
+	Transient map[string][]byte
+	// TxID is the ID of the transaction. A new ID is generated if it is empty.
+	TxID string
+	// Function is the name of the transaction function invoked, as returned by
+	// GetFunctionAndParameters.
+	Function string
+}
+
+// NewContext returns the context of a new transaction. The transaction is not
+// simulated until the context is passed to a contract function.
+func (l *Ledger) NewContext(tx Transaction) *Context {
+	if tx.TxID == "" {
+		l.txCount++
+		tx.TxID = fmt.Sprintf("tx%d", l.txCount)
+	}
+	if tx.PeerMSPID == "" && tx.Client != nil {
+		tx.PeerMSPID = tx.Client.MSPID
+	}
+
+	stub := &Stub{
+		ledger:    l,
+		txID:      tx.TxID,
+		timestamp: &timestamp.Timestamp{Seconds: l.Clock.Unix(), Nanos: int32(l.Clock.Nanosecond())},
+		transient: tx.Transient,
+		function:  tx.Function,
+		peerMSPID: tx.PeerMSPID,
+		reads:     make(map[string]uint64),
+		writes:    make(map[string]Write),
+		private:   make(map[string]map[string]Write),
+		metadata:  make(map[string][]byte),
+	}
+
+	return &Context{stub: stub, client: tx.Client}
+}
+
+// Execute simulates a transaction on the peer of the transaction, without
+// committing it. shim.GetMSPID returns the MSP ID of the peer while fn runs.
+func (l *Ledger) Execute(tx Transaction, fn func(ctx contractapi.TransactionContextInterface) error) (*Context, error) {
+	ctx := l.NewContext(tx)
+
+	err := ctx.Run(fn)
+
+	return ctx, err
+}
+
+// Submit simulates a transaction and commits it if it succeeds.
+func (l *Ledger) Submit(tx Transaction, fn func(ctx contractapi.TransactionContextInterface) error) error {
+	ctx, err := l.Execute(tx, fn)
+	if err != nil {
+		return err
+	}
+
+	return l.Commit(ctx)
+}
+
+// Commit applies the writes of a simulated transaction to the ledger. The
+// transaction is rejected if a key it read was changed since it was simulated,
+// or if a key was added to or deleted from a range it read.
+func (l *Ledger) Commit(ctx *Context) error {
+	stub := ctx.stub
+
+	for key, version := range stub.reads {
+		if l.versions[key] != version {
+			return fmt.Errorf("MVCC read conflict on key %v", key)
+		}
+	}
+
+	for _, keyRange := range stub.ranges {
+		if !equalKeys(l.rangeKeys(keyRange.startKey, keyRange.endKey), keyRange.keys) {
+			return fmt.Errorf("phantom read conflict on range %q to %q", keyRange.startKey, keyRange.endKey)
+		}
+	}
+
+	l.blockNumber++
+
+	for key, write := range stub.writes {
+		modification := &queryresult.KeyModification{
+			TxId:      stub.txID,
+			Timestamp: stub.timestamp,
+			IsDelete:  write.IsDelete,
+		}
+
+		if write.IsDelete {
+			delete(l.state, key)
+			delete(l.versions, key)
+			delete(l.validation, key)
+		} else {
+			l.state[key] = write.Value
+			l.versions[key] = l.blockNumber
+			modification.Value = write.Value
+		}
+
+		l.history[key] = append(l.history[key], modification)
+	}
+
+	for key, ep := range stub.metadata {
+		l.validation[key] = ep
+	}
+
+	for collection, writes := range stub.private {
+		if l.private[collection] == nil {
+			l.private[collection] = make(map[string][]byte)
+		}
+
+		for key, write := range writes {
+			if write.IsDelete {
+				delete(l.private[collection], key)
+			} else {
+				l.private[collection][key] = write.Value
+			}
+		}
+	}
+
+	return nil
+}
+
+// keyRange is a range of keys read by a transaction, with the keys it held.
+type keyRange struct {
+	startKey string
+	endKey   string
+	keys     []string
+}
+
+// rangeKeys returns the sorted committed keys in a range.
+func (l *Ledger) rangeKeys(startKey string, endKey string) []string {
+	keys := []string{}
+	for key := range l.state {
+		if key >= startKey && (endKey == "" || key < endKey) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// equalKeys returns whether two sorted lists of keys are the same.
+func equalKeys(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetState returns the committed value of a key, for assertions in tests.
+func (l *Ledger) GetState(key string) []byte {
+	return l.state[key]
+}
+
+// GetStateValidationParameter returns the committed state validation parameter
+// of a key, for assertions in tests.
+func (l *Ledger) GetStateValidationParameter(key string) []byte {
+	return l.validation[key]
+}
+
+// GetPrivateData returns the committed value of a key in a collection, for
+// assertions in tests.
+func (l *Ledger) GetPrivateData(collection string, key string) []byte {
+	return l.private[collection][key]
+}
+
+// isCollectionMember returns true if the peer of the given organization stores
+// the private data of the collection. Only implicit collections are supported.
+func isCollectionMember(collection string, peerMSPID string) bool {
+	return strings.TrimPrefix(collection, implicitCollectionPrefix) == peerMSPID
+}
+
+// withPeerMSPID runs fn with the MSP ID of the peer set for shim.GetMSPID.
+func withPeerMSPID(peerMSPID string, fn func() error) error {
+	previous, set := os.LookupEnv(peerMSPIDEnv)
+	os.Setenv(peerMSPIDEnv, peerMSPID)
+
+	defer func() {
+		if set {
+			os.Setenv(peerMSPIDEnv, previous)
+		} else {
+			os.Unsetenv(peerMSPIDEnv)
+		}
+	}()
+
+	return fn()
+}
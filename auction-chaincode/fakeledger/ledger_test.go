@@ -0,0 +1,148 @@
+package fakeledger
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritesAreOnlyVisibleOnceCommitted(t *testing.T) {
+	ledger := New()
+	client := NewIdentity("Org1MSP", "alice")
+
+	ctx, err := ledger.Execute(Transaction{Client: client}, func(ctx contractapi.TransactionContextInterface) error {
+		err := ctx.GetStub().PutState("key", []byte("value"))
+		require.NoError(t, err)
+
+		value, err := ctx.GetStub().GetState("key")
+		require.NoError(t, err)
+		require.Nil(t, value)
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.Nil(t, ledger.GetState("key"))
+
+	err = ledger.Commit(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), ledger.GetState("key"))
+}
+
+func TestCommitRejectsStaleReads(t *testing.T) {
+	ledger := New()
+	client := NewIdentity("Org1MSP", "alice")
+
+	read := func(ctx contractapi.TransactionContextInterface) error {
+		_, err := ctx.GetStub().GetState("key")
+		if err != nil {
+			return err
+		}
+
+		return ctx.GetStub().PutState("key", []byte(ctx.GetStub().GetTxID()))
+	}
+
+	first, err := ledger.Execute(Transaction{Client: client}, read)
+	require.NoError(t, err)
+	second, err := ledger.Execute(Transaction{Client: client}, read)
+	require.NoError(t, err)
+
+	require.NoError(t, ledger.Commit(first))
+	require.EqualError(t, ledger.Commit(second), "MVCC read conflict on key key")
+}
+
+func TestCommitRejectsPhantomReads(t *testing.T) {
+	ledger := New()
+	client := NewIdentity("Org1MSP", "alice")
+
+	scan, err := ledger.Execute(Transaction{Client: client}, func(ctx contractapi.TransactionContextInterface) error {
+		iterator, err := ctx.GetStub().GetStateByRange("a", "c")
+		if err != nil {
+			return err
+		}
+		iterator.Close()
+
+		return ctx.GetStub().PutState("count", []byte("0"))
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, ledger.Submit(Transaction{Client: client}, func(ctx contractapi.TransactionContextInterface) error {
+		return ctx.GetStub().PutState("b", []byte("value"))
+	}))
+
+	require.EqualError(t, ledger.Commit(scan), `phantom read conflict on range "a" to "c"`)
+}
+
+func TestPrivateDataIsOnlyReadableByMemberPeers(t *testing.T) {
+	ledger := New()
+	client := NewIdentity("Org1MSP", "alice")
+	collection := "_implicit_org_Org1MSP"
+
+	err := ledger.Submit(Transaction{Client: client}, func(ctx contractapi.TransactionContextInterface) error {
+		peerMSPID, err := shim.GetMSPID()
+		require.NoError(t, err)
+		require.Equal(t, "Org1MSP", peerMSPID)
+
+		return ctx.GetStub().PutPrivateData(collection, "key", []byte("secret"))
+	})
+	require.NoError(t, err)
+
+	_, err = ledger.Execute(Transaction{Client: client, PeerMSPID: "Org2MSP"}, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := ctx.GetStub().GetPrivateData(collection, "key")
+		require.Error(t, err)
+
+		hash, err := ctx.GetStub().GetPrivateDataHash(collection, "key")
+		require.NoError(t, err)
+
+		expected := sha256.Sum256([]byte("secret"))
+		require.Equal(t, expected[:], hash)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestPartialCompositeKeyPagination(t *testing.T) {
+	ledger := New()
+	client := NewIdentity("Org1MSP", "alice")
+
+	err := ledger.Submit(Transaction{Client: client}, func(ctx contractapi.TransactionContextInterface) error {
+		for _, id := range []string{"a", "b", "c"} {
+			key, err := ctx.GetStub().CreateCompositeKey("status~auction", []string{"open", id})
+			require.NoError(t, err)
+			require.NoError(t, ctx.GetStub().PutState(key, []byte{0x00}))
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = ledger.Execute(Transaction{Client: client}, func(ctx contractapi.TransactionContextInterface) error {
+		bookmark := ""
+		ids := []string{}
+		for {
+			iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination("status~auction", []string{"open"}, 2, bookmark)
+			require.NoError(t, err)
+
+			for iterator.HasNext() {
+				result, err := iterator.Next()
+				require.NoError(t, err)
+
+				_, attributes, err := ctx.GetStub().SplitCompositeKey(result.Key)
+				require.NoError(t, err)
+				ids = append(ids, attributes[1])
+			}
+
+			bookmark = metadata.Bookmark
+			if bookmark == "" {
+				break
+			}
+		}
+		require.Equal(t, []string{"a", "b", "c"}, ids)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
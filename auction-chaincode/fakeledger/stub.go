@@ -0,0 +1,555 @@
+package fakeledger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+const (
+	compositeKeyNamespace = "\x00"
+	minUnicodeRuneValue   = 0
+	maxUnicodeRuneValue   = utf8.MaxRune
+)
+
+// Write is a write of a key by a transaction.
+type Write struct {
+	Value    []byte
+	IsDelete bool
+}
+
+// Event is the chaincode event set by a transaction.
+type Event struct {
+	Name    string
+	Payload []byte
+}
+
+// Stub implements shim.ChaincodeStubInterface for a simulated transaction.
+type Stub struct {
+	ledger    *Ledger
+	txID      string
+	timestamp *timestamp.Timestamp
+	transient map[string][]byte
+	function  string
+	peerMSPID string
+	reads     map[string]uint64
+	ranges    []keyRange
+	writes    map[string]Write
+	private   map[string]map[string]Write
+	metadata  map[string][]byte
+	event     *Event
+}
+
+// Reads returns the version of every public key read by the transaction.
+func (s *Stub) Reads() map[string]uint64 {
+	return s.reads
+}
+
+// Writes returns the public writes of the transaction.
+func (s *Stub) Writes() map[string]Write {
+	return s.writes
+}
+
+// PrivateWrites returns the private data writes of the transaction, by collection.
+func (s *Stub) PrivateWrites() map[string]map[string]Write {
+	return s.private
+}
+
+// ValidationParameterWrites returns the state validation parameters set by the
+// transaction.
+func (s *Stub) ValidationParameterWrites() map[string][]byte {
+	return s.metadata
+}
+
+// Event returns the chaincode event of the transaction, or nil.
+func (s *Stub) Event() *Event {
+	return s.event
+}
+
+// GetArgs is not supported, contract functions are called directly.
+func (s *Stub) GetArgs() [][]byte {
+	return nil
+}
+
+// GetStringArgs is not supported, contract functions are called directly.
+func (s *Stub) GetStringArgs() []string {
+	return nil
+}
+
+// GetFunctionAndParameters returns the function of the transaction, without
+// parameters since contract functions are called directly.
+func (s *Stub) GetFunctionAndParameters() (string, []string) {
+	return s.function, nil
+}
+
+// GetArgsSlice is not supported, contract functions are called directly.
+func (s *Stub) GetArgsSlice() ([]byte, error) {
+	return nil, fmt.Errorf("GetArgsSlice is not supported")
+}
+
+// GetTxID returns the ID of the transaction.
+func (s *Stub) GetTxID() string {
+	return s.txID
+}
+
+// GetChannelID returns the channel of the ledger.
+func (s *Stub) GetChannelID() string {
+	return ChannelID
+}
+
+// InvokeChaincode is not supported.
+func (s *Stub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) pb.Response {
+	return shim.Error("InvokeChaincode is not supported")
+}
+
+// GetState returns the committed value of a key and records the read.
+func (s *Stub) GetState(key string) ([]byte, error) {
+	s.reads[key] = s.ledger.versions[key]
+
+	return s.ledger.state[key], nil
+}
+
+// PutState buffers the write of a key.
+func (s *Stub) PutState(key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("key must not be an empty string")
+	}
+	if len(value) == 0 {
+		return fmt.Errorf("value of key %v must not be empty", key)
+	}
+
+	s.writes[key] = Write{Value: value}
+
+	return nil
+}
+
+// DelState buffers the delete of a key.
+func (s *Stub) DelState(key string) error {
+	s.writes[key] = Write{IsDelete: true}
+
+	return nil
+}
+
+// SetStateValidationParameter buffers the state validation parameter of a key.
+func (s *Stub) SetStateValidationParameter(key string, ep []byte) error {
+	s.metadata[key] = ep
+
+	return nil
+}
+
+// GetStateValidationParameter returns the committed state validation parameter
+// of a key.
+func (s *Stub) GetStateValidationParameter(key string) ([]byte, error) {
+	return s.ledger.validation[key], nil
+}
+
+// GetStateByRange returns an iterator over the committed keys in the range, and
+// records the range so that keys added or deleted in it invalidate the
+// transaction.
+func (s *Stub) GetStateByRange(startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
+	keys := s.rangeKeys(startKey, endKey)
+	s.ranges = append(s.ranges, keyRange{startKey: startKey, endKey: endKey, keys: keys})
+
+	return s.newStateIterator(keys), nil
+}
+
+// GetStateByRangeWithPagination returns a page of the committed keys in the range.
+func (s *Stub) GetStateByRangeWithPagination(startKey string, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	keys, metadata := paginate(s.rangeKeys(startKey, endKey), pageSize, bookmark)
+
+	return s.newStateIterator(keys), metadata, nil
+}
+
+// GetStateByPartialCompositeKey returns an iterator over the committed composite
+// keys that start with the object type and attributes.
+func (s *Stub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	startKey, endKey, err := partialCompositeKeyRange(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetStateByRange(startKey, endKey)
+}
+
+// GetStateByPartialCompositeKeyWithPagination returns a page of the committed
+// composite keys that start with the object type and attributes.
+func (s *Stub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	startKey, endKey, err := partialCompositeKeyRange(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.GetStateByRangeWithPagination(startKey, endKey, pageSize, bookmark)
+}
+
+// CreateCompositeKey combines the object type and attributes into a key.
+func (s *Stub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return createCompositeKey(objectType, attributes)
+}
+
+// SplitCompositeKey splits a key into the object type and attributes.
+func (s *Stub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	if !strings.HasPrefix(compositeKey, compositeKeyNamespace) || !strings.HasSuffix(compositeKey, "\x00") {
+		return "", nil, fmt.Errorf("%v is not a composite key", compositeKey)
+	}
+
+	components := strings.Split(compositeKey[1:len(compositeKey)-1], "\x00")
+
+	return components[0], components[1:], nil
+}
+
+// GetQueryResult runs a rich query over the committed JSON values. Only selectors
+// that compare top level fields for equality are supported.
+func (s *Stub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	keys, err := s.queryKeys(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.newStateIterator(keys), nil
+}
+
+// GetQueryResultWithPagination runs a rich query and returns a page of the results.
+func (s *Stub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	keys, err := s.queryKeys(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys, metadata := paginate(keys, pageSize, bookmark)
+
+	return s.newStateIterator(keys), metadata, nil
+}
+
+// GetHistoryForKey returns the committed versions of a key, newest first.
+func (s *Stub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	history := s.ledger.history[key]
+
+	modifications := make([]*queryresult.KeyModification, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		modifications = append(modifications, history[i])
+	}
+
+	return &historyIterator{modifications: modifications}, nil
+}
+
+// GetPrivateData returns the committed value of a key in a collection. Only
+// peers of the organization of an implicit collection can read it.
+func (s *Stub) GetPrivateData(collection string, key string) ([]byte, error) {
+	if !isCollectionMember(collection, s.peerMSPID) {
+		return nil, fmt.Errorf("peer of %v is not a member of collection %v", s.peerMSPID, collection)
+	}
+
+	return s.ledger.private[collection][key], nil
+}
+
+// GetPrivateDataHash returns the hash of the committed value of a key in a
+// collection. Any peer can read the hash.
+func (s *Stub) GetPrivateDataHash(collection string, key string) ([]byte, error) {
+	value, ok := s.ledger.private[collection][key]
+	if !ok {
+		return nil, nil
+	}
+
+	hash := sha256.Sum256(value)
+
+	return hash[:], nil
+}
+
+// PutPrivateData buffers the write of a key in a collection.
+func (s *Stub) PutPrivateData(collection string, key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("key must not be an empty string")
+	}
+	if len(value) == 0 {
+		return fmt.Errorf("value of key %v must not be empty", key)
+	}
+
+	s.privateWrites(collection)[key] = Write{Value: value}
+
+	return nil
+}
+
+// DelPrivateData buffers the delete of a key in a collection.
+func (s *Stub) DelPrivateData(collection string, key string) error {
+	s.privateWrites(collection)[key] = Write{IsDelete: true}
+
+	return nil
+}
+
+// SetPrivateDataValidationParameter is not supported.
+func (s *Stub) SetPrivateDataValidationParameter(collection string, key string, ep []byte) error {
+	return fmt.Errorf("SetPrivateDataValidationParameter is not supported")
+}
+
+// GetPrivateDataValidationParameter is not supported.
+func (s *Stub) GetPrivateDataValidationParameter(collection string, key string) ([]byte, error) {
+	return nil, fmt.Errorf("GetPrivateDataValidationParameter is not supported")
+}
+
+// GetPrivateDataByRange is not supported.
+func (s *Stub) GetPrivateDataByRange(collection string, startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
+	return nil, fmt.Errorf("GetPrivateDataByRange is not supported")
+}
+
+// GetPrivateDataByPartialCompositeKey is not supported.
+func (s *Stub) GetPrivateDataByPartialCompositeKey(collection string, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	return nil, fmt.Errorf("GetPrivateDataByPartialCompositeKey is not supported")
+}
+
+// GetPrivateDataQueryResult is not supported.
+func (s *Stub) GetPrivateDataQueryResult(collection string, query string) (shim.StateQueryIteratorInterface, error) {
+	return nil, fmt.Errorf("GetPrivateDataQueryResult is not supported")
+}
+
+// GetCreator is not supported, the client identity is set on the context.
+func (s *Stub) GetCreator() ([]byte, error) {
+	return nil, fmt.Errorf("GetCreator is not supported")
+}
+
+// GetTransient returns the transient map of the proposal.
+func (s *Stub) GetTransient() (map[string][]byte, error) {
+	if s.transient == nil {
+		return map[string][]byte{}, nil
+	}
+
+	return s.transient, nil
+}
+
+// GetBinding is not supported.
+func (s *Stub) GetBinding() ([]byte, error) {
+	return nil, fmt.Errorf("GetBinding is not supported")
+}
+
+// GetDecorations returns no decorations.
+func (s *Stub) GetDecorations() map[string][]byte {
+	return nil
+}
+
+// GetSignedProposal is not supported.
+func (s *Stub) GetSignedProposal() (*pb.SignedProposal, error) {
+	return nil, fmt.Errorf("GetSignedProposal is not supported")
+}
+
+// GetTxTimestamp returns the timestamp of the transaction.
+func (s *Stub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return s.timestamp, nil
+}
+
+// SetEvent sets the chaincode event of the transaction.
+func (s *Stub) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return fmt.Errorf("event name can not be empty string")
+	}
+
+	s.event = &Event{Name: name, Payload: payload}
+
+	return nil
+}
+
+// privateWrites returns the buffered writes of a collection.
+func (s *Stub) privateWrites(collection string) map[string]Write {
+	if s.private[collection] == nil {
+		s.private[collection] = make(map[string]Write)
+	}
+
+	return s.private[collection]
+}
+
+// rangeKeys returns the sorted committed keys in the range and records the reads.
+// An empty end key means the end of the key space.
+func (s *Stub) rangeKeys(startKey string, endKey string) []string {
+	keys := s.ledger.rangeKeys(startKey, endKey)
+
+	for _, key := range keys {
+		s.reads[key] = s.ledger.versions[key]
+	}
+
+	return keys
+}
+
+// queryKeys returns the sorted committed keys whose JSON value matches the
+// selector of the query.
+func (s *Stub) queryKeys(query string) ([]string, error) {
+	var parsed struct {
+		Selector map[string]interface{} `json:"selector"`
+	}
+
+	err := json.Unmarshal([]byte(query), &parsed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %v: %v", query, err)
+	}
+
+	keys := []string{}
+	for key, value := range s.ledger.state {
+		var document map[string]interface{}
+		if json.Unmarshal(value, &document) != nil {
+			continue
+		}
+
+		matches := true
+		for field, expected := range parsed.Selector {
+			if !reflect.DeepEqual(document[field], expected) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// newStateIterator returns an iterator over the committed values of the keys.
+func (s *Stub) newStateIterator(keys []string) *stateIterator {
+	results := make([]*queryresult.KV, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, &queryresult.KV{Namespace: ChannelID, Key: key, Value: s.ledger.state[key]})
+	}
+
+	return &stateIterator{results: results}
+}
+
+// paginate returns the page of sorted keys that starts at the bookmark. The
+// bookmark of the next page is empty when there are no more keys.
+func paginate(keys []string, pageSize int32, bookmark string) ([]string, *pb.QueryResponseMetadata) {
+	start := 0
+	if bookmark != "" {
+		start = sort.SearchStrings(keys, bookmark)
+	}
+
+	end := start + int(pageSize)
+	if pageSize <= 0 || end > len(keys) {
+		end = len(keys)
+	}
+
+	next := ""
+	if end < len(keys) {
+		next = keys[end]
+	}
+
+	page := keys[start:end]
+
+	return page, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(page)), Bookmark: next}
+}
+
+// createCompositeKey combines the object type and attributes into a key, in the
+// same format as the shim.
+func createCompositeKey(objectType string, attributes []string) (string, error) {
+	err := validateCompositeKeyAttribute(objectType)
+	if err != nil {
+		return "", err
+	}
+
+	var key bytes.Buffer
+	key.WriteString(compositeKeyNamespace + objectType + "\x00")
+
+	for _, attribute := range attributes {
+		err = validateCompositeKeyAttribute(attribute)
+		if err != nil {
+			return "", err
+		}
+
+		key.WriteString(attribute + "\x00")
+	}
+
+	return key.String(), nil
+}
+
+// validateCompositeKeyAttribute checks that an attribute can be part of a
+// composite key.
+func validateCompositeKeyAttribute(attribute string) error {
+	if !utf8.ValidString(attribute) {
+		return fmt.Errorf("not a valid utf8 string: [%x]", attribute)
+	}
+
+	for index, runeValue := range attribute {
+		if runeValue == minUnicodeRuneValue || runeValue == maxUnicodeRuneValue {
+			return fmt.Errorf("input contains unicode %#U starting at position [%d]. %#U and %#U are not allowed in the input attribute of a composite key",
+				runeValue, index, minUnicodeRuneValue, maxUnicodeRuneValue)
+		}
+	}
+
+	return nil
+}
+
+// partialCompositeKeyRange returns the range of keys that start with the
+// object type and attributes.
+func partialCompositeKeyRange(objectType string, attributes []string) (string, string, error) {
+	startKey, err := createCompositeKey(objectType, attributes)
+	if err != nil {
+		return "", "", err
+	}
+
+	return startKey, startKey + string(maxUnicodeRuneValue), nil
+}
+
+// stateIterator implements shim.StateQueryIteratorInterface.
+type stateIterator struct {
+	results []*queryresult.KV
+	index   int
+}
+
+// HasNext returns true if the iterator has more results.
+func (i *stateIterator) HasNext() bool {
+	return i.index < len(i.results)
+}
+
+// Next returns the next result.
+func (i *stateIterator) Next() (*queryresult.KV, error) {
+	if !i.HasNext() {
+		return nil, fmt.Errorf("no more results")
+	}
+
+	result := i.results[i.index]
+	i.index++
+
+	return result, nil
+}
+
+// Close closes the iterator.
+func (i *stateIterator) Close() error {
+	return nil
+}
+
+// historyIterator implements shim.HistoryQueryIteratorInterface.
+type historyIterator struct {
+	modifications []*queryresult.KeyModification
+	index         int
+}
+
+// HasNext returns true if the iterator has more results.
+func (i *historyIterator) HasNext() bool {
+	return i.index < len(i.modifications)
+}
+
+// Next returns the next result.
+func (i *historyIterator) Next() (*queryresult.KeyModification, error) {
+	if !i.HasNext() {
+		return nil, fmt.Errorf("no more results")
+	}
+
+	modification := i.modifications[i.index]
+	i.index++
+
+	return modification, nil
+}
+
+// Close closes the iterator.
+func (i *historyIterator) Close() error {
+	return nil
+}
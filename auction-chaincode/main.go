@@ -1,10 +1,14 @@
 package main
 
 import (
+	"io/ioutil"
 	"log"
+	"os"
+	"strconv"
 
 	. "auction-chaincode/contract"
 
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/hyperledger/fabric-contract-api-go/metadata"
 )
@@ -17,7 +21,12 @@ func main() {
 	auctionContract.Info.Contact = new(metadata.ContactMetadata)
 	auctionContract.Info.Contact.Name = "Esteban Velasquez"
 
-	chaincode, err := contractapi.NewChaincode(auctionContract)
+	tokenContract := new(TokenContract)
+	tokenContract.Name = TokenContractName
+	tokenContract.Info.Version = "0.0.1"
+	tokenContract.Info.Description = "Auction Payment Token Contract"
+
+	chaincode, err := contractapi.NewChaincode(auctionContract, tokenContract)
 	chaincode.Info.Title = "auction-chaincode chaincode"
 	chaincode.Info.Version = "0.0.1"
 
@@ -25,9 +34,81 @@ func main() {
 		log.Panicf("Error creating AuctionContract chaincode: %v", err)
 	}
 
-	err = chaincode.Start()
+	// The chaincode runs as an external service when a server address is set,
+	// and is launched by the peer otherwise.
+	if os.Getenv("CHAINCODE_SERVER_ADDRESS") != "" {
+		server := &shim.ChaincodeServer{
+			CCID:     os.Getenv("CHAINCODE_ID"),
+			Address:  os.Getenv("CHAINCODE_SERVER_ADDRESS"),
+			CC:       chaincode,
+			TLSProps: getTLSProperties(),
+		}
+
+		err = server.Start()
+	} else {
+		err = chaincode.Start()
+	}
 
 	if err != nil {
 		log.Panicf("Error starting AuctionContract chaincode: %v", err)
 	}
 }
+
+// getTLSProperties reads the TLS settings of the chaincode server from the
+// environment. TLS is enabled when the key and the certificate of the server
+// are both set, and the server does not start when only one of them is set, so
+// that a missing setting cannot silently disable TLS. CHAINCODE_TLS_DISABLED
+// overrides the default when it is set.
+func getTLSProperties() shim.TLSProperties {
+	keySet := os.Getenv("CHAINCODE_TLS_KEY") != ""
+	certSet := os.Getenv("CHAINCODE_TLS_CERT") != ""
+	if keySet != certSet {
+		log.Panicf("CHAINCODE_TLS_KEY and CHAINCODE_TLS_CERT must be set together")
+	}
+
+	tlsDisabled := !keySet
+	if value := os.Getenv("CHAINCODE_TLS_DISABLED"); value != "" {
+		disabled, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Panicf("Invalid CHAINCODE_TLS_DISABLED value %v: %v", value, err)
+		}
+		tlsDisabled = disabled
+	}
+
+	if tlsDisabled {
+		if os.Getenv("CHAINCODE_CLIENT_CA_CERT") != "" {
+			log.Panicf("CHAINCODE_CLIENT_CA_CERT is set, but TLS is disabled")
+		}
+		return shim.TLSProperties{Disabled: true}
+	}
+
+	key := readFile("CHAINCODE_TLS_KEY", true)
+	cert := readFile("CHAINCODE_TLS_CERT", true)
+	// The peer is only verified when a client CA is set.
+	clientCACert := readFile("CHAINCODE_CLIENT_CA_CERT", false)
+
+	return shim.TLSProperties{
+		Disabled:      false,
+		Key:           key,
+		Cert:          cert,
+		ClientCACerts: clientCACert,
+	}
+}
+
+// readFile reads the file named by an environment variable.
+func readFile(name string, required bool) []byte {
+	path := os.Getenv(name)
+	if path == "" {
+		if required {
+			log.Panicf("%v must be set when TLS is enabled", name)
+		}
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Panicf("Failed to read %v file %v: %v", name, path, err)
+	}
+
+	return content
+}
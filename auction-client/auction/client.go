@@ -0,0 +1,597 @@
+// Package auction is a Go client of the auction chaincode. It wraps every
+// transaction of the AuctionContract and the TokenContract with typed arguments
+// and results, builds
+// the transient bids and reserves like the scripts of the auction application,
+// and sends each transaction to the organizations that have to endorse it.
+package auction
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// saltLength is the number of random bytes in the salt of a bid or a reserve.
+const saltLength = 32
+
+// tokenContract is the name of the token contract of the chaincode.
+const tokenContract = "token"
+
+// Transaction is a transaction to submit to the auction chaincode.
+type Transaction struct {
+	Name          string
+	Args          []string
+	Transient     map[string][]byte
+	EndorsingOrgs []string
+}
+
+// Gateway evaluates and submits transactions of the auction chaincode. If the
+// endorsing organizations of a transaction are not set, the gateway chooses them.
+type Gateway interface {
+	Evaluate(name string, args ...string) ([]byte, error)
+	Submit(tx Transaction) ([]byte, error)
+}
+
+// Client runs the auction workflow for a client identity of an organization.
+type Client struct {
+	gateway Gateway
+	mspID   string
+}
+
+// New returns a client that uses the gateway of the organization with the
+// given MSP ID.
+func New(gateway Gateway, mspID string) *Client {
+	return &Client{gateway: gateway, mspID: mspID}
+}
+
+// GetSubmittingClientIdentity returns the ID of the client identity.
+func (c *Client) GetSubmittingClientIdentity() (string, error) {
+	id, err := c.gateway.Evaluate("GetSubmittingClientIdentity")
+	if err != nil {
+		return "", fmt.Errorf("Failed to get client identity: %v", err)
+	}
+
+	return string(id), nil
+}
+
+// GetMetadata returns the metadata of the auction chaincode, generated by the
+// contract API.
+func (c *Client) GetMetadata() ([]byte, error) {
+	metadata, err := c.gateway.Evaluate("org.hyperledger.fabric:GetMetadata")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get chaincode metadata: %v", err)
+	}
+
+	return metadata, nil
+}
+
+// RegisterItem adds an item owned by the client to the registry.
+func (c *Client) RegisterItem(itemID string, description string) error {
+	_, err := c.gateway.Submit(Transaction{
+		Name:          "RegisterItem",
+		Args:          []string{itemID, description},
+		EndorsingOrgs: []string{c.mspID},
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to register item %v: %v", itemID, err)
+	}
+
+	return nil
+}
+
+// ReadItem returns an item of the registry.
+func (c *Client) ReadItem(itemID string) (*Item, error) {
+	item := new(Item)
+
+	err := c.evaluate(item, "ReadItem", itemID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read item %v: %v", itemID, err)
+	}
+
+	return item, nil
+}
+
+// CreateAuction creates an auction that sells an item of the client. The item
+// is locked by the peers of the organization of the seller, which endorse the
+// transaction. Bidders post the deposit with their bids, and forfeit it if they
+// do not reveal their bids in time. The auction sells the given quantity of
+// units of the item. If the reserve is positive, it is stored in the private
+// data collection of that organization. If invitees are given, as MSP IDs of
+// organizations or IDs of client identities, only they can bid on the auction,
+// and only they and the seller see the list.
+func (c *Client) CreateAuction(auctionID string, item string, format string, biddingDeadline time.Time, revealDeadline time.Time, deposit int, quantity int, reserve int, invitees ...string) error {
+	if invitees == nil {
+		invitees = []string{}
+	}
+	inviteesArg, err := json.Marshal(invitees)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal invitees: %v", err)
+	}
+
+	tx := Transaction{
+		Name: "CreateAuction",
+		Args: []string{
+			auctionID,
+			item,
+			format,
+			strconv.FormatInt(biddingDeadline.Unix(), 10),
+			strconv.FormatInt(revealDeadline.Unix(), 10),
+			strconv.Itoa(deposit),
+			strconv.Itoa(quantity),
+			string(inviteesArg),
+		},
+		EndorsingOrgs: []string{c.mspID},
+		Transient:     map[string][]byte{},
+	}
+
+	if reserve > 0 {
+		seller, err := c.GetSubmittingClientIdentity()
+		if err != nil {
+			return err
+		}
+
+		// The random salt keeps the reserve from being guessed from its hash.
+		salt, err := newSalt()
+		if err != nil {
+			return err
+		}
+
+		transientReserve, err := json.Marshal(Reserve{Type: "reserve", Price: reserve, Seller: seller, Salt: salt})
+		if err != nil {
+			return fmt.Errorf("Failed to marshal reserve: %v", err)
+		}
+
+		tx.Transient["reserve"] = transientReserve
+	}
+
+	_, err = c.gateway.Submit(tx)
+	if err != nil {
+		return fmt.Errorf("Failed to create auction %v: %v", auctionID, err)
+	}
+
+	return nil
+}
+
+// CreateDutchAuction creates a dutch auction that sells an item of the client.
+// The price drops from the start price by the decrement every interval, down to
+// the floor price, until a buyer accepts it or the deadline passes.
+func (c *Client) CreateDutchAuction(auctionID string, item string, startPrice int, floorPrice int, decrement int, interval time.Duration, deadline time.Time) error {
+	tx := Transaction{
+		Name: "CreateDutchAuction",
+		Args: []string{
+			auctionID,
+			item,
+			strconv.Itoa(startPrice),
+			strconv.Itoa(floorPrice),
+			strconv.Itoa(decrement),
+			strconv.FormatInt(int64(interval/time.Second), 10),
+			strconv.FormatInt(deadline.Unix(), 10),
+		},
+		EndorsingOrgs: []string{c.mspID},
+	}
+
+	_, err := c.gateway.Submit(tx)
+	if err != nil {
+		return fmt.Errorf("Failed to create dutch auction %v: %v", auctionID, err)
+	}
+
+	return nil
+}
+
+// AcceptPrice buys the item of a dutch auction at its current price, paid from
+// the token account of the client.
+func (c *Client) AcceptPrice(auctionID string) error {
+	return c.submitToAuction(Transaction{Name: "AcceptPrice", Args: []string{auctionID}})
+}
+
+// CreateEnglishAuction creates an english auction that sells an item of the
+// client. Bids are public, and have to beat the high bid by the minimum
+// increment until the deadline.
+func (c *Client) CreateEnglishAuction(auctionID string, item string, startPrice int, minIncrement int, deadline time.Time) error {
+	tx := Transaction{
+		Name: "CreateEnglishAuction",
+		Args: []string{
+			auctionID,
+			item,
+			strconv.Itoa(startPrice),
+			strconv.Itoa(minIncrement),
+			strconv.FormatInt(deadline.Unix(), 10),
+		},
+		EndorsingOrgs: []string{c.mspID},
+	}
+
+	_, err := c.gateway.Submit(tx)
+	if err != nil {
+		return fmt.Errorf("Failed to create english auction %v: %v", auctionID, err)
+	}
+
+	return nil
+}
+
+// PlaceOpenBid places a public bid on an english auction. The price of the bid is
+// held from the token account of the client until the client is outbid or the
+// auction ends.
+func (c *Client) PlaceOpenBid(auctionID string, price int) error {
+	return c.submitToAuction(Transaction{Name: "PlaceOpenBid", Args: []string{auctionID, strconv.Itoa(price)}})
+}
+
+// CreateDoubleAuction creates a double auction run by the client, where buyers
+// and sellers trade units of an item with sealed orders.
+func (c *Client) CreateDoubleAuction(auctionID string, item string, biddingDeadline time.Time, revealDeadline time.Time, deposit int) error {
+	tx := Transaction{
+		Name: "CreateDoubleAuction",
+		Args: []string{
+			auctionID,
+			item,
+			strconv.FormatInt(biddingDeadline.Unix(), 10),
+			strconv.FormatInt(revealDeadline.Unix(), 10),
+			strconv.Itoa(deposit),
+		},
+		EndorsingOrgs: []string{c.mspID},
+	}
+
+	_, err := c.gateway.Submit(tx)
+	if err != nil {
+		return fmt.Errorf("Failed to create double auction %v: %v", auctionID, err)
+	}
+
+	return nil
+}
+
+// ClearAuction matches the revealed orders of a closed double auction, and
+// ends it.
+func (c *Client) ClearAuction(auctionID string) error {
+	return c.submitToAuction(Transaction{Name: "ClearAuction", Args: []string{auctionID}})
+}
+
+// QueryAuction returns an auction with its private and revealed bids.
+func (c *Client) QueryAuction(auctionID string) (*Auction, error) {
+	auction := new(Auction)
+
+	err := c.evaluate(auction, "QueryAuction", auctionID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to query auction %v: %v", auctionID, err)
+	}
+
+	return auction, nil
+}
+
+// CreateBid stores a salted bid in the private data collection of the
+// organization of the bidder, and returns the ID of the bid. The bid asks for
+// a quantity of units at a unit price.
+func (c *Client) CreateBid(auctionID string, price int, quantity int) (string, error) {
+	return c.createBid(auctionID, "", price, quantity)
+}
+
+// CreateOrder stores a salted order of a double auction like a bid, and returns
+// the ID of the order. A bid side order buys units at most at the unit price,
+// and an ask side order sells units at least at the unit price.
+func (c *Client) CreateOrder(auctionID string, side string, price int, quantity int) (string, error) {
+	return c.createBid(auctionID, side, price, quantity)
+}
+
+// createBid stores a salted bid, or an order of a double auction with a side.
+func (c *Client) createBid(auctionID string, side string, price int, quantity int) (string, error) {
+	bidder, err := c.GetSubmittingClientIdentity()
+	if err != nil {
+		return "", err
+	}
+
+	// The random salt keeps the price from being guessed from the bid hash that
+	// is published on the auction.
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+
+	transientBid, err := json.Marshal(FullBid{
+		Type:     "bid",
+		Price:    price,
+		Quantity: quantity,
+		Side:     side,
+		Org:      c.mspID,
+		Bidder:   bidder,
+		Salt:     salt,
+	})
+	if err != nil {
+		return "", fmt.Errorf("Failed to marshal bid: %v", err)
+	}
+
+	bidID, err := c.gateway.Submit(Transaction{
+		Name:          "CreateBid",
+		Args:          []string{auctionID},
+		Transient:     map[string][]byte{"bid": transientBid},
+		EndorsingOrgs: []string{c.mspID},
+	})
+	if err != nil {
+		return "", fmt.Errorf("Failed to create bid on auction %v: %v", auctionID, err)
+	}
+
+	return string(bidID), nil
+}
+
+// QueryBid returns a bid of the client identity.
+func (c *Client) QueryBid(auctionID string, bidID string) (*FullBid, error) {
+	bid := new(FullBid)
+
+	err := c.evaluate(bid, "QueryBid", auctionID, bidID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to query bid %v: %v", bidID, err)
+	}
+
+	return bid, nil
+}
+
+// QueryReserve returns the reserve of an auction of the client identity.
+func (c *Client) QueryReserve(auctionID string) (*Reserve, error) {
+	reserve := new(Reserve)
+
+	err := c.evaluate(reserve, "QueryReserve", auctionID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to query reserve of auction %v: %v", auctionID, err)
+	}
+
+	return reserve, nil
+}
+
+// SubmitBid adds the hash of a bid to an auction, and holds the maximum price of
+// the bid from the token account of the client until the auction ends.
+func (c *Client) SubmitBid(auctionID string, bidID string, maxPrice int) error {
+	return c.submitToAuction(Transaction{Name: "SubmitBid", Args: []string{auctionID, bidID, strconv.Itoa(maxPrice)}})
+}
+
+// RevealBid reveals a bid once the auction is closed.
+func (c *Client) RevealBid(auctionID string, bidID string) error {
+	transientBid, err := c.transientBid(auctionID, bidID)
+	if err != nil {
+		return err
+	}
+
+	return c.submitToAuction(Transaction{
+		Name:      "RevealBid",
+		Args:      []string{auctionID, bidID},
+		Transient: map[string][]byte{"bid": transientBid},
+	})
+}
+
+// WithdrawBid withdraws a bid while the auction is open.
+func (c *Client) WithdrawBid(auctionID string, bidID string) error {
+	transientBid, err := c.transientBid(auctionID, bidID)
+	if err != nil {
+		return err
+	}
+
+	return c.submitToAuction(Transaction{
+		Name:      "WithdrawBid",
+		Args:      []string{auctionID, bidID},
+		Transient: map[string][]byte{"bid": transientBid},
+	})
+}
+
+// CloseAuction closes an auction to new bids.
+func (c *Client) CloseAuction(auctionID string) error {
+	return c.submitToAuction(Transaction{Name: "CloseAuction", Args: []string{auctionID}})
+}
+
+// EndAuction ends an auction. If the auction has a reserve and the client is the
+// seller, or an auctioneer of the seller's organization, the reserve is revealed.
+// The other clients end the auction without the reserve, which the chaincode only
+// allows once the reveal period of the reserve has passed.
+func (c *Client) EndAuction(auctionID string) error {
+	auction, err := c.QueryAuction(auctionID)
+	if err != nil {
+		return err
+	}
+
+	tx := Transaction{Name: "EndAuction", Args: []string{auctionID}, EndorsingOrgs: auction.Orgs}
+
+	if auction.ReserveHash != "" {
+		transientReserve, err := c.transientReserve(auction)
+		if err != nil {
+			return err
+		}
+
+		if transientReserve != nil {
+			tx.Transient = map[string][]byte{"reserve": transientReserve}
+		}
+	}
+
+	_, err = c.gateway.Submit(tx)
+	if err != nil {
+		return fmt.Errorf("Failed to end auction %v: %v", auctionID, err)
+	}
+
+	return nil
+}
+
+// CancelAuction cancels an auction.
+func (c *Client) CancelAuction(auctionID string) error {
+	return c.submitToAuction(Transaction{Name: "CancelAuction", Args: []string{auctionID}})
+}
+
+// AddInvitee adds an organization, given by its MSP ID, or a client identity,
+// given by its ID, to the invitees of an invite-only auction of the client.
+func (c *Client) AddInvitee(auctionID string, invitee string) error {
+	return c.submitToAuction(Transaction{Name: "AddInvitee", Args: []string{auctionID, invitee}})
+}
+
+// Mint creates tokens in the account of the client. Only clients of the minter
+// organization can mint tokens.
+func (c *Client) Mint(amount int) error {
+	_, err := c.gateway.Submit(Transaction{Name: tokenContract + ":Mint", Args: []string{strconv.Itoa(amount)}})
+	if err != nil {
+		return fmt.Errorf("Failed to mint tokens: %v", err)
+	}
+
+	return nil
+}
+
+// Transfer moves tokens from the account of the client to the account of the
+// recipient, given as the ID of a client identity.
+func (c *Client) Transfer(recipient string, amount int) error {
+	_, err := c.gateway.Submit(Transaction{Name: tokenContract + ":Transfer", Args: []string{recipient, strconv.Itoa(amount)}})
+	if err != nil {
+		return fmt.Errorf("Failed to transfer tokens to %v: %v", recipient, err)
+	}
+
+	return nil
+}
+
+// ClientAccountBalance returns the tokens of the client that are not held for a bid.
+func (c *Client) ClientAccountBalance() (int, error) {
+	var balance int
+
+	err := c.evaluate(&balance, tokenContract+":ClientAccountBalance")
+	if err != nil {
+		return 0, fmt.Errorf("Failed to get balance of client account: %v", err)
+	}
+
+	return balance, nil
+}
+
+// BalanceOf returns the tokens of a client identity that are not held for a bid.
+func (c *Client) BalanceOf(owner string) (int, error) {
+	var balance int
+
+	err := c.evaluate(&balance, tokenContract+":BalanceOf", owner)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to get balance of account %v: %v", owner, err)
+	}
+
+	return balance, nil
+}
+
+// GetAuctionHistory returns every committed version of an auction. The history
+// covers the auction itself, not its sealed bids.
+func (c *Client) GetAuctionHistory(auctionID string) ([]*AuctionHistoryEntry, error) {
+	history := []*AuctionHistoryEntry{}
+
+	err := c.evaluate(&history, "GetAuctionHistory", auctionID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get history of auction %v: %v", auctionID, err)
+	}
+
+	return history, nil
+}
+
+// ListAuctionsByStatus returns a page of the auctions with the given status.
+func (c *Client) ListAuctionsByStatus(status string, pageSize int32, bookmark string) (*AuctionQueryResult, error) {
+	return c.listAuctions("ListAuctionsByStatus", status, strconv.Itoa(int(pageSize)), bookmark)
+}
+
+// ListAuctionsBySeller returns a page of the auctions created by the given seller.
+func (c *Client) ListAuctionsBySeller(seller string, pageSize int32, bookmark string) (*AuctionQueryResult, error) {
+	return c.listAuctions("ListAuctionsBySeller", seller, strconv.Itoa(int(pageSize)), bookmark)
+}
+
+// ListAuctionsByItem returns a page of the auctions that sell the given item.
+func (c *Client) ListAuctionsByItem(item string, pageSize int32, bookmark string) (*AuctionQueryResult, error) {
+	return c.listAuctions("ListAuctionsByItem", item, strconv.Itoa(int(pageSize)), bookmark)
+}
+
+// SearchAuctions returns a page of the auctions matching the given status,
+// seller and item. Empty filters are ignored.
+func (c *Client) SearchAuctions(status string, seller string, item string, pageSize int32, bookmark string) (*AuctionQueryResult, error) {
+	return c.listAuctions("SearchAuctions", status, seller, item, strconv.Itoa(int(pageSize)), bookmark)
+}
+
+// listAuctions evaluates a transaction that returns a page of auctions.
+func (c *Client) listAuctions(name string, args ...string) (*AuctionQueryResult, error) {
+	result := new(AuctionQueryResult)
+
+	err := c.evaluate(result, name, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list auctions: %v", err)
+	}
+
+	return result, nil
+}
+
+// submitToAuction submits a transaction that has to be endorsed by the
+// organizations of an auction. The auction is the first argument.
+func (c *Client) submitToAuction(tx Transaction) error {
+	auctionID := tx.Args[0]
+
+	auction, err := c.QueryAuction(auctionID)
+	if err != nil {
+		return err
+	}
+
+	tx.EndorsingOrgs = auction.Orgs
+
+	_, err = c.gateway.Submit(tx)
+	if err != nil {
+		return fmt.Errorf("Failed to submit %v on auction %v: %v", tx.Name, auctionID, err)
+	}
+
+	return nil
+}
+
+// transientBid returns a bid as it is stored in the private data collection, so
+// that its hash matches the hash of the private bid. The bid is passed through
+// unchanged, whatever the JSON encoding of the client that created it.
+func (c *Client) transientBid(auctionID string, bidID string) ([]byte, error) {
+	transientBid, err := c.gateway.Evaluate("QueryStoredBid", auctionID, bidID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to query bid %v: %v", bidID, err)
+	}
+
+	return transientBid, nil
+}
+
+// transientReserve returns the reserve of an auction as it is stored in the
+// private data collection of the seller's organization, to pass it unchanged to
+// EndAuction so that its hash matches. It returns nil for the clients that cannot
+// read the reserve: the clients of other organizations, and the clients of the
+// seller's organization that do not act for the seller.
+func (c *Client) transientReserve(auction *Auction) ([]byte, error) {
+	if len(auction.Orgs) == 0 || auction.Orgs[0] != c.mspID {
+		return nil, nil
+	}
+
+	clientID, err := c.GetSubmittingClientIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	transientReserve, err := c.gateway.Evaluate("QueryStoredReserve", auction.ID)
+	if err != nil && clientID == auction.Seller {
+		return nil, fmt.Errorf("Failed to query reserve of auction %v: %v", auction.ID, err)
+	}
+	if err != nil {
+		// Only an auctioneer can read the reserve for the seller.
+		return nil, nil
+	}
+
+	return transientReserve, nil
+}
+
+// newSalt returns a random hex encoded salt.
+func newSalt() (string, error) {
+	salt := make([]byte, saltLength)
+
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", fmt.Errorf("Failed to generate salt: %v", err)
+	}
+
+	return hex.EncodeToString(salt), nil
+}
+
+// evaluate evaluates a transaction and unmarshals its JSON result.
+func (c *Client) evaluate(result interface{}, name string, args ...string) error {
+	response, err := c.gateway.Evaluate(name, args...)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(response, result)
+	if err != nil {
+		return fmt.Errorf("Failed to unmarshal result of %v: %v", name, err)
+	}
+
+	return nil
+}
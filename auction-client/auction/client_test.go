@@ -0,0 +1,426 @@
+package auction_test
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"auction-chaincode/contract"
+	"auction-chaincode/fakeledger"
+	"auction-client/auction"
+	"auction-client/fakegateway"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+)
+
+// newClient returns a client of an identity that can both sell and bid.
+func newClient(ledger *fakeledger.Ledger, mspID string, name string) *auction.Client {
+	return newClientWithRole(ledger, mspID, name, "seller,bidder")
+}
+
+// newClientWithRole returns a client of an identity with the given value of the
+// auction.role attribute.
+func newClientWithRole(ledger *fakeledger.Ledger, mspID string, name string, role string) *auction.Client {
+	return auction.New(fakegateway.New(ledger, fakeledger.NewIdentity(mspID, name, "auction.role", role)), mspID)
+}
+
+// fund mints tokens and transfers them to the account of a client.
+func fund(t *testing.T, ledger *fakeledger.Ledger, client *auction.Client, amount int) {
+	minter := newClient(ledger, "Org1MSP", "minter")
+	require.NoError(t, minter.Mint(amount))
+
+	recipient, err := client.GetSubmittingClientIdentity()
+	require.NoError(t, err)
+	require.NoError(t, minter.Transfer(recipient, amount))
+}
+
+func TestAuctionWorkflow(t *testing.T) {
+	ledger := fakeledger.New()
+	seller := newClient(ledger, "Org1MSP", "seller")
+	bidder1 := newClient(ledger, "Org1MSP", "bidder1")
+	bidder2 := newClient(ledger, "Org2MSP", "bidder2")
+	fund(t, ledger, bidder1, 1000)
+	fund(t, ledger, bidder2, 1000)
+
+	require.NoError(t, seller.RegisterItem("painting", "Oil on canvas"))
+
+	now := ledger.Clock
+	err := seller.CreateAuction("1", "painting", "second-price", now.Add(time.Hour), now.Add(2*time.Hour), 10, 1, 150)
+	require.NoError(t, err)
+
+	bid1, err := bidder1.CreateBid("1", 100, 1)
+	require.NoError(t, err)
+	require.NoError(t, bidder1.SubmitBid("1", bid1, 500))
+
+	bid2, err := bidder2.CreateBid("1", 300, 1)
+	require.NoError(t, err)
+	require.NoError(t, bidder2.SubmitBid("1", bid2, 500))
+
+	balance, err := bidder2.ClientAccountBalance()
+	require.NoError(t, err)
+	require.Equal(t, 490, balance)
+
+	auction, err := seller.QueryAuction("1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Org1MSP", "Org2MSP"}, auction.Orgs)
+	require.Len(t, auction.PrivateBids, 2)
+
+	ledger.Advance(time.Hour)
+	require.NoError(t, seller.CloseAuction("1"))
+	require.NoError(t, bidder1.RevealBid("1", bid1))
+	require.NoError(t, bidder2.RevealBid("1", bid2))
+	require.NoError(t, seller.EndAuction("1"))
+
+	auction, err = seller.QueryAuction("1")
+	require.NoError(t, err)
+	require.Equal(t, "ended", auction.Status)
+	require.Equal(t, 150, auction.Price)
+
+	winner, err := bidder2.GetSubmittingClientIdentity()
+	require.NoError(t, err)
+	require.Equal(t, winner, auction.Winner)
+
+	// The winner paid the price to the seller.
+	balance, err = bidder2.BalanceOf(winner)
+	require.NoError(t, err)
+	require.Equal(t, 850, balance)
+
+	balance, err = seller.ClientAccountBalance()
+	require.NoError(t, err)
+	require.Equal(t, 150, balance)
+
+	// The item was transferred to the winner.
+	item, err := seller.ReadItem("painting")
+	require.NoError(t, err)
+	require.Equal(t, winner, item.Owner)
+	require.Empty(t, item.AuctionID)
+
+	result, err := seller.ListAuctionsByStatus("ended", 10, "")
+	require.NoError(t, err)
+	require.Len(t, result.Auctions, 1)
+}
+
+func TestCreateBidStoresSaltedBid(t *testing.T) {
+	ledger := fakeledger.New()
+	seller := newClient(ledger, "Org1MSP", "seller")
+	bidder := newClient(ledger, "Org2MSP", "bidder")
+
+	require.NoError(t, seller.RegisterItem("painting", "Oil on canvas"))
+
+	now := ledger.Clock
+	err := seller.CreateAuction("1", "painting", "first-price", now.Add(time.Hour), now.Add(2*time.Hour), 10, 5, 0)
+	require.NoError(t, err)
+
+	bidID, err := bidder.CreateBid("1", 100, 2)
+	require.NoError(t, err)
+
+	bid, err := bidder.QueryBid("1", bidID)
+	require.NoError(t, err)
+	require.Equal(t, 100, bid.Price)
+	require.Equal(t, 2, bid.Quantity)
+	require.Equal(t, "Org2MSP", bid.Org)
+	require.Len(t, bid.Salt, 64)
+
+	// The private bid is encoded like the bid built by the createBid.js script,
+	// so that either client can reveal it.
+	bidKey := "\x00bid\x001\x00" + bidID + "\x00"
+	expected := `{"objectType":"bid","price":100,"quantity":2,"org":"Org2MSP","bidder":"` + bid.Bidder + `","salt":"` + bid.Salt + `"}`
+	require.Equal(t, expected, string(ledger.GetPrivateData("_implicit_org_Org2MSP", bidKey)))
+}
+
+func TestRevealBidPassesStoredBidThrough(t *testing.T) {
+	ledger := fakeledger.New()
+	seller := newClient(ledger, "Org1MSP", "seller")
+	identity := fakeledger.NewIdentity("Org2MSP", "bidder", "auction.role", "bidder")
+	bidder := auction.New(fakegateway.New(ledger, identity), "Org2MSP")
+	fund(t, ledger, bidder, 1000)
+
+	require.NoError(t, seller.RegisterItem("painting", "Oil on canvas"))
+
+	now := ledger.Clock
+	err := seller.CreateAuction("1", "painting", "first-price", now.Add(time.Hour), now.Add(2*time.Hour), 10, 1, 0)
+	require.NoError(t, err)
+
+	// Another client created the bid with its fields in a different order.
+	salt := strings.Repeat("0123456789abcdef", 4)
+	bid := `{"salt":"` + salt + `","bidder":"` + identity.ID() + `","org":"Org2MSP","quantity":1,"price":100,"objectType":"bid"}`
+
+	var bidID string
+	err = ledger.Submit(fakeledger.Transaction{Client: identity, Transient: map[string][]byte{"bid": []byte(bid)}}, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		bidID, err = new(contract.AuctionContract).CreateBid(ctx, "1")
+		return err
+	})
+	require.NoError(t, err)
+	require.NoError(t, bidder.SubmitBid("1", bidID, 200))
+
+	ledger.Advance(time.Hour)
+	require.NoError(t, seller.CloseAuction("1"))
+	require.NoError(t, bidder.RevealBid("1", bidID))
+
+	result, err := seller.QueryAuction("1")
+	require.NoError(t, err)
+	require.Len(t, result.RevealedBids, 1)
+}
+
+func TestDutchAuction(t *testing.T) {
+	ledger := fakeledger.New()
+	seller := newClient(ledger, "Org1MSP", "seller")
+	buyer := newClient(ledger, "Org2MSP", "buyer")
+	fund(t, ledger, buyer, 1000)
+
+	require.NoError(t, seller.RegisterItem("painting", "Oil on canvas"))
+
+	err := seller.CreateDutchAuction("1", "painting", 500, 200, 50, time.Minute, ledger.Clock.Add(time.Hour))
+	require.NoError(t, err)
+
+	ledger.Advance(3 * time.Minute)
+
+	auction, err := buyer.QueryAuction("1")
+	require.NoError(t, err)
+	require.Equal(t, int64(60), auction.DecrementInterval)
+	require.Equal(t, 350, auction.Price)
+
+	require.NoError(t, buyer.AcceptPrice("1"))
+
+	balance, err := seller.ClientAccountBalance()
+	require.NoError(t, err)
+	require.Equal(t, 350, balance)
+
+	item, err := buyer.ReadItem("painting")
+	require.NoError(t, err)
+	require.Equal(t, "Org2MSP", item.OwnerOrg)
+}
+
+func TestEnglishAuction(t *testing.T) {
+	ledger := fakeledger.New()
+	seller := newClient(ledger, "Org1MSP", "seller")
+	bidder1 := newClient(ledger, "Org1MSP", "bidder1")
+	bidder2 := newClient(ledger, "Org2MSP", "bidder2")
+	fund(t, ledger, bidder1, 1000)
+	fund(t, ledger, bidder2, 1000)
+
+	require.NoError(t, seller.RegisterItem("painting", "Oil on canvas"))
+	require.NoError(t, seller.CreateEnglishAuction("1", "painting", 100, 10, ledger.Clock.Add(time.Hour)))
+
+	require.NoError(t, bidder1.PlaceOpenBid("1", 100))
+	require.NoError(t, bidder2.PlaceOpenBid("1", 150))
+	require.Error(t, bidder1.PlaceOpenBid("1", 155))
+	require.NoError(t, bidder1.PlaceOpenBid("1", 160))
+
+	ledger.Advance(time.Hour + time.Second)
+	require.NoError(t, bidder2.EndAuction("1"))
+
+	auction, err := seller.QueryAuction("1")
+	require.NoError(t, err)
+	require.Equal(t, "ended", auction.Status)
+	require.Equal(t, 160, auction.Price)
+	require.Equal(t, []string{"Org1MSP", "Org2MSP"}, auction.Orgs)
+
+	balance, err := bidder2.ClientAccountBalance()
+	require.NoError(t, err)
+	require.Equal(t, 1000, balance)
+}
+
+func TestDoubleAuction(t *testing.T) {
+	ledger := fakeledger.New()
+	market := newClient(ledger, "Org1MSP", "market")
+	seller := newClient(ledger, "Org1MSP", "seller")
+	buyer := newClient(ledger, "Org2MSP", "buyer")
+	fund(t, ledger, seller, 100)
+	fund(t, ledger, buyer, 1000)
+
+	now := ledger.Clock
+	require.NoError(t, market.CreateDoubleAuction("1", "apples", now.Add(time.Hour), now.Add(2*time.Hour), 5))
+
+	ask, err := seller.CreateOrder("1", "ask", 10, 5)
+	require.NoError(t, err)
+	require.NoError(t, seller.SubmitBid("1", ask, 0))
+
+	bid, err := buyer.CreateOrder("1", "bid", 20, 3)
+	require.NoError(t, err)
+	require.NoError(t, buyer.SubmitBid("1", bid, 60))
+
+	ledger.Advance(time.Hour)
+	require.NoError(t, market.CloseAuction("1"))
+	require.NoError(t, seller.RevealBid("1", ask))
+	require.NoError(t, buyer.RevealBid("1", bid))
+	require.NoError(t, market.ClearAuction("1"))
+
+	auction, err := market.QueryAuction("1")
+	require.NoError(t, err)
+	require.Equal(t, 15, auction.Price)
+	require.Zero(t, auction.Quantity)
+	require.Len(t, auction.Matches, 1)
+	require.Equal(t, 3, auction.Matches[0].Quantity)
+
+	balance, err := seller.ClientAccountBalance()
+	require.NoError(t, err)
+	require.Equal(t, 145, balance)
+}
+
+func TestInviteOnlyAuction(t *testing.T) {
+	ledger := fakeledger.New()
+	seller := newClient(ledger, "Org1MSP", "seller")
+	invitee := newClient(ledger, "Org2MSP", "invitee")
+	outsider := newClient(ledger, "Org3MSP", "outsider")
+	fund(t, ledger, invitee, 1000)
+	fund(t, ledger, outsider, 1000)
+
+	require.NoError(t, seller.RegisterItem("painting", "Oil on canvas"))
+
+	now := ledger.Clock
+	err := seller.CreateAuction("1", "painting", "first-price", now.Add(time.Hour), now.Add(2*time.Hour), 10, 1, 0, "Org2MSP")
+	require.NoError(t, err)
+
+	bid, err := invitee.CreateBid("1", 100, 1)
+	require.NoError(t, err)
+	require.NoError(t, invitee.SubmitBid("1", bid, 200))
+
+	bid, err = outsider.CreateBid("1", 100, 1)
+	require.NoError(t, err)
+	require.Error(t, outsider.SubmitBid("1", bid, 200))
+
+	auction, err := outsider.QueryAuction("1")
+	require.NoError(t, err)
+	require.True(t, auction.InviteOnly)
+	require.Empty(t, auction.Invitees)
+
+	require.NoError(t, seller.AddInvitee("1", "Org3MSP"))
+	require.NoError(t, outsider.SubmitBid("1", bid, 200))
+
+	auction, err = outsider.QueryAuction("1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Org2MSP", "Org3MSP"}, auction.Invitees)
+}
+
+func TestRolesAreChecked(t *testing.T) {
+	ledger := fakeledger.New()
+	seller := newClientWithRole(ledger, "Org1MSP", "seller", "seller")
+	auctioneer := newClientWithRole(ledger, "Org1MSP", "auctioneer", "auctioneer")
+	bidder := newClientWithRole(ledger, "Org2MSP", "bidder", "bidder")
+	fund(t, ledger, bidder, 1000)
+
+	require.NoError(t, seller.RegisterItem("painting", "Oil on canvas"))
+	require.NoError(t, bidder.RegisterItem("sculpture", "Marble"))
+
+	now := ledger.Clock
+	err := bidder.CreateAuction("1", "sculpture", "first-price", now.Add(time.Hour), now.Add(2*time.Hour), 10, 1, 0)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "requires the seller role")
+
+	err = seller.CreateAuction("1", "painting", "first-price", now.Add(time.Hour), now.Add(2*time.Hour), 10, 1, 0)
+	require.NoError(t, err)
+
+	_, err = seller.CreateBid("1", 100, 1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "requires the bidder role")
+
+	bid, err := bidder.CreateBid("1", 100, 1)
+	require.NoError(t, err)
+	require.NoError(t, bidder.SubmitBid("1", bid, 200))
+
+	// The auctioneer closes and ends the auction for the seller.
+	require.NoError(t, auctioneer.CloseAuction("1"))
+	require.NoError(t, bidder.RevealBid("1", bid))
+	require.NoError(t, auctioneer.EndAuction("1"))
+
+	auction, err := seller.QueryAuction("1")
+	require.NoError(t, err)
+	require.Equal(t, "ended", auction.Status)
+	require.Equal(t, 100, auction.Price)
+}
+
+func TestEndAuctionWithReserveByOtherClients(t *testing.T) {
+	ledger := fakeledger.New()
+	seller := newClientWithRole(ledger, "Org1MSP", "seller", "seller")
+	auctioneer := newClientWithRole(ledger, "Org1MSP", "auctioneer", "auctioneer")
+	colleague := newClientWithRole(ledger, "Org1MSP", "colleague", "bidder")
+	bidder := newClientWithRole(ledger, "Org2MSP", "bidder", "bidder")
+	fund(t, ledger, bidder, 1000)
+
+	require.NoError(t, seller.RegisterItem("painting", "Oil on canvas"))
+	require.NoError(t, seller.RegisterItem("sculpture", "Marble"))
+
+	// The auctioneer reveals the reserve of the seller before the reveal deadline.
+	now := ledger.Clock
+	err := seller.CreateAuction("1", "painting", "first-price", now.Add(time.Hour), now.Add(2*time.Hour), 10, 1, 50)
+	require.NoError(t, err)
+
+	bid, err := bidder.CreateBid("1", 100, 1)
+	require.NoError(t, err)
+	require.NoError(t, bidder.SubmitBid("1", bid, 200))
+	require.NoError(t, auctioneer.CloseAuction("1"))
+	require.NoError(t, bidder.RevealBid("1", bid))
+
+	require.Error(t, colleague.EndAuction("1"))
+	require.NoError(t, auctioneer.EndAuction("1"))
+
+	sold, err := seller.QueryAuction("1")
+	require.NoError(t, err)
+	require.Equal(t, "ended", sold.Status)
+	require.Equal(t, "sold", sold.Outcome)
+	require.Equal(t, 100, sold.Price)
+
+	// Clients that cannot read the reserve end the auction without it once the
+	// seller has withheld it past its reveal period.
+	for i, client := range []*auction.Client{bidder, colleague} {
+		auctionID := strconv.Itoa(i + 2)
+
+		now = ledger.Clock
+		err = seller.CreateAuction(auctionID, "sculpture", "first-price", now.Add(time.Hour), now.Add(2*time.Hour), 10, 1, 50)
+		require.NoError(t, err)
+
+		bid, err = bidder.CreateBid(auctionID, 100, 1)
+		require.NoError(t, err)
+		require.NoError(t, bidder.SubmitBid(auctionID, bid, 200))
+		require.NoError(t, seller.CloseAuction(auctionID))
+		require.NoError(t, bidder.RevealBid(auctionID, bid))
+
+		ledger.Advance(3 * time.Hour)
+		require.Error(t, client.EndAuction(auctionID))
+
+		ledger.Advance(24 * time.Hour)
+		require.NoError(t, client.EndAuction(auctionID))
+
+		ended, err := seller.QueryAuction(auctionID)
+		require.NoError(t, err)
+		require.Equal(t, "ended", ended.Status)
+		require.Equal(t, "reserve-not-met", ended.Outcome)
+	}
+}
+
+func TestTypesMatchChaincode(t *testing.T) {
+	pairs := []struct {
+		client    interface{}
+		chaincode interface{}
+	}{
+		{auction.Auction{}, contract.Auction{}},
+		{auction.Allocation{}, contract.Allocation{}},
+		{auction.Match{}, contract.Match{}},
+		{auction.FullBid{}, contract.FullBid{}},
+		{auction.BidHash{}, contract.BidHash{}},
+		{auction.Reserve{}, contract.Reserve{}},
+		{auction.AuctionQueryResult{}, contract.AuctionQueryResult{}},
+		{auction.AuctionHistoryEntry{}, contract.AuctionHistoryEntry{}},
+		{auction.Item{}, contract.Item{}},
+	}
+
+	for _, pair := range pairs {
+		require.Equal(t, jsonFields(reflect.TypeOf(pair.chaincode)), jsonFields(reflect.TypeOf(pair.client)), reflect.TypeOf(pair.client).Name())
+	}
+}
+
+// jsonFields returns the JSON tags and kinds of the fields of a struct.
+func jsonFields(structType reflect.Type) []string {
+	fields := []string{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		fields = append(fields, field.Tag.Get("json")+" "+field.Type.Kind().String())
+	}
+
+	return fields
+}
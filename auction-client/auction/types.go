@@ -0,0 +1,124 @@
+package auction
+
+// The types below match the JSON of the types of the auction chaincode. They are
+// declared again so that this package, and the commands built on the Fabric
+// Gateway, do not link the chaincode and its dependencies. Only the fakegateway
+// package, which runs the chaincode for tests, and the tests themselves link it.
+
+// Auction stores auction's data
+type Auction struct {
+	Type            string             `json:"objectType"`
+	ID              string             `json:"id"`
+	ItemSold        string             `json:"item"`
+	Quantity        int                `json:"quantity,omitempty"`
+	Format          string             `json:"format"`
+	Seller          string             `json:"seller"`
+	ReserveHash     string             `json:"reserveHash"`
+	Orgs            []string           `json:"organizations"`
+	PrivateBids     map[string]BidHash `json:"privateBids,omitempty"`
+	RevealedBids    map[string]FullBid `json:"revealedBids,omitempty"`
+	Winner          string             `json:"winner"`
+	WinningBid      int                `json:"winningBid"`
+	Price           int                `json:"price"`
+	Status          string             `json:"status"`
+	BiddingDeadline int64              `json:"biddingDeadline"`
+	RevealDeadline  int64              `json:"revealDeadline"`
+	Deposit         int                `json:"deposit"`
+	Outcome         string             `json:"outcome"`
+	Allocations     []Allocation       `json:"allocations,omitempty"`
+	Matches         []Match            `json:"matches,omitempty"`
+	TieBreak        string             `json:"tieBreak,omitempty"`
+	Tie             bool               `json:"tie"`
+	InviteOnly      bool               `json:"inviteOnly"`
+	Invitees        []string           `json:"invitees,omitempty"`
+
+	// Price schedule of a dutch auction.
+	StartPrice        int   `json:"startPrice,omitempty"`
+	FloorPrice        int   `json:"floorPrice,omitempty"`
+	PriceDecrement    int   `json:"priceDecrement,omitempty"`
+	DecrementInterval int64 `json:"decrementInterval,omitempty"`
+	StartTime         int64 `json:"startTime,omitempty"`
+
+	// Minimum increment of the bids of an english auction.
+	MinIncrement int `json:"minIncrement,omitempty"`
+}
+
+// Allocation stores the units of an ended auction won by a bid, and the unit
+// price paid for them.
+type Allocation struct {
+	BidKey   string `json:"bidKey,omitempty"`
+	Bidder   string `json:"bidder"`
+	Org      string `json:"org"`
+	Quantity int    `json:"quantity"`
+	Price    int    `json:"price"`
+}
+
+// Match stores units of a double auction traded between a buy order and a sell
+// order, and the unit price paid for them.
+type Match struct {
+	BidKey    string `json:"bidKey"`
+	Buyer     string `json:"buyer"`
+	BuyerOrg  string `json:"buyerOrg"`
+	AskKey    string `json:"askKey"`
+	Seller    string `json:"seller"`
+	SellerOrg string `json:"sellerOrg"`
+	Quantity  int    `json:"quantity"`
+	Price     int    `json:"price"`
+}
+
+// FullBid stores revealed bid's data. The price is the price of one unit. The
+// orders of a double auction also have a side.
+type FullBid struct {
+	Type     string `json:"objectType"`
+	Price    int    `json:"price"`
+	Quantity int    `json:"quantity"`
+	Side     string `json:"side,omitempty"`
+	Org      string `json:"org"`
+	Bidder   string `json:"bidder"`
+	Salt     string `json:"salt,omitempty"`
+}
+
+// BidHash stores private bid's data, and the timestamp of the transaction that
+// submitted it.
+type BidHash struct {
+	Org       string `json:"org"`
+	Hash      string `json:"hash"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Reserve stores the seller's private reserve price
+type Reserve struct {
+	Type   string `json:"objectType"`
+	Price  int    `json:"price"`
+	Seller string `json:"seller"`
+	Salt   string `json:"salt"`
+}
+
+// AuctionQueryResult stores a page of auctions and the bookmark used to query
+// the next page.
+type AuctionQueryResult struct {
+	Auctions            []*Auction `json:"auctions"`
+	FetchedRecordsCount int32      `json:"fetchedRecordsCount"`
+	Bookmark            string     `json:"bookmark"`
+}
+
+// AuctionHistoryEntry stores a committed version of an auction, along with the
+// lifecycle step that produced it.
+type AuctionHistoryEntry struct {
+	TxID      string   `json:"txID"`
+	Timestamp int64    `json:"timestamp"`
+	IsDelete  bool     `json:"isDelete"`
+	Step      string   `json:"step"`
+	Auction   *Auction `json:"auction,omitempty"`
+}
+
+// Item stores an item of the registry and its owner. An item that is sold by an
+// auction is locked by the ID of the auction.
+type Item struct {
+	Type        string `json:"objectType"`
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Owner       string `json:"owner"`
+	OwnerOrg    string `json:"ownerOrg"`
+	AuctionID   string `json:"auctionID"`
+}
@@ -0,0 +1,116 @@
+// Command auction-rest serves the auctions and bids of the auction chaincode as
+// REST resources. Requests are authenticated by bearer tokens, read from a JSON
+// file that maps each identity, as <org>/<user>, to its token. Requests run as
+// the identity of their token, which must be enrolled in the wallet of the
+// auction application. Anyone who can read the token file can act as any of its
+// identities, and the service listens on localhost unless told otherwise.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"auction-client/auction"
+	"auction-client/fabricgateway"
+	"auction-client/rest"
+)
+
+// walletSubmitter connects the identities of the wallet to the Fabric test
+// network. Connections are kept open and reused by later requests.
+type walletSubmitter struct {
+	cfg      fabricgateway.Config
+	mutex    sync.Mutex
+	gateways map[string]*fabricgateway.Gateway
+	mspIDs   map[string]string
+}
+
+// Gateway returns the gateway of an identity, given as <org>/<user>.
+func (s *walletSubmitter) Gateway(identity string) (auction.Gateway, string, error) {
+	org, user, ok := strings.Cut(identity, "/")
+	if !ok || org == "" || user == "" {
+		return nil, "", fmt.Errorf("Identity must be given as <org>/<user>")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if gateway, ok := s.gateways[identity]; ok {
+		return gateway, s.mspIDs[identity], nil
+	}
+
+	gateway, mspID, err := fabricgateway.Connect(s.cfg, org, user)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.gateways[identity] = gateway
+	s.mspIDs[identity] = mspID
+
+	return gateway, mspID, nil
+}
+
+// readTokens reads the bearer tokens of the identities from a JSON file that maps
+// each identity to its token.
+func readTokens(path string) (rest.TokenAuthenticator, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read tokens: %v", err)
+	}
+
+	var identityTokens map[string]string
+	err = json.Unmarshal(bytes, &identityTokens)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal tokens: %v", err)
+	}
+
+	tokens := rest.TokenAuthenticator{}
+	for identity, token := range identityTokens {
+		if token == "" {
+			return nil, fmt.Errorf("Token of identity %v must not be empty", identity)
+		}
+		if _, ok := tokens[token]; ok {
+			return nil, fmt.Errorf("Token of identity %v is issued to another identity", identity)
+		}
+		tokens[token] = identity
+	}
+
+	return tokens, nil
+}
+
+func main() {
+	home, _ := os.UserHomeDir()
+
+	addr := flag.String("addr", "localhost:8080", "address the service listens on")
+	tokensPath := flag.String("tokens", "", "JSON file of the bearer token of each identity, as <org>/<user>")
+
+	var cfg fabricgateway.Config
+	flag.StringVar(&cfg.WalletPath, "wallet", filepath.Join("auction-application", "src", "wallet"), "directory of the wallets of the auction application")
+	flag.StringVar(&cfg.NetworkPath, "network", filepath.Join(home, "fabric-samples", "test-network"), "directory of the Fabric test network")
+	flag.StringVar(&cfg.ChannelName, "channel", "mychannel", "channel of the auction chaincode")
+	flag.StringVar(&cfg.ChaincodeName, "chaincode", "auction-chaincode", "name of the auction chaincode")
+	flag.Parse()
+
+	if *tokensPath == "" {
+		log.Fatal("The -tokens flag is required")
+	}
+	tokens, err := readTokens(*tokensPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	submitter := &walletSubmitter{
+		cfg:      cfg,
+		gateways: map[string]*fabricgateway.Gateway{},
+		mspIDs:   map[string]string{},
+	}
+
+	log.Printf("Serving auctions on %v", *addr)
+	log.Fatal(http.ListenAndServe(*addr, rest.NewServer(tokens, submitter)))
+}
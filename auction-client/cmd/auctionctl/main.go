@@ -0,0 +1,509 @@
+// Command auctionctl runs the auction workflow from the command line, with the
+// same commands as the scripts of the auction application. It uses the
+// identities enrolled in the wallet of the auction application.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"auction-client/auction"
+	"auction-client/fabricgateway"
+)
+
+// command is a command of the CLI.
+type command struct {
+	args string
+	run  func(c *auction.Client, args []string) error
+}
+
+var commands = map[string]command{
+	"registerItem": {
+		args: "<itemID> <description>",
+		run: func(c *auction.Client, args []string) error {
+			err := c.RegisterItem(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			return readItem(c, args[0])
+		},
+	},
+	"readItem": {
+		args: "<itemID>",
+		run: func(c *auction.Client, args []string) error {
+			return readItem(c, args[0])
+		},
+	},
+	"createAuction": {
+		args: "<auctionID> <item> <format> <biddingMinutes> <revealMinutes> <deposit> <quantity> [reserve] [invitee...]",
+		run:  createAuction,
+	},
+	"createDutchAuction": {
+		args: "<auctionID> <item> <startPrice> <floorPrice> <decrement> <intervalMinutes> <minutes>",
+		run:  createDutchAuction,
+	},
+	"createEnglishAuction": {
+		args: "<auctionID> <item> <startPrice> <minIncrement> <minutes>",
+		run:  createEnglishAuction,
+	},
+	"placeOpenBid": {
+		args: "<auctionID> <price>",
+		run: func(c *auction.Client, args []string) error {
+			price, err := strconv.Atoi(args[1])
+			if err != nil || price <= 0 {
+				return fmt.Errorf("Price must be a positive number")
+			}
+
+			return submitAndQuery(c, args[0], c.PlaceOpenBid(args[0], price))
+		},
+	},
+	"acceptPrice": {
+		args: "<auctionID>",
+		run: func(c *auction.Client, args []string) error {
+			return submitAndQuery(c, args[0], c.AcceptPrice(args[0]))
+		},
+	},
+	"createBid": {
+		args: "<auctionID> <price> [quantity]",
+		run:  createBid,
+	},
+	"createDoubleAuction": {
+		args: "<auctionID> <item> <biddingMinutes> <revealMinutes> <deposit>",
+		run:  createDoubleAuction,
+	},
+	"createOrder": {
+		args: "<auctionID> <side> <price> <quantity>",
+		run:  createOrder,
+	},
+	"submitBid": {
+		args: "<auctionID> <bidID> <maxPrice>",
+		run: func(c *auction.Client, args []string) error {
+			// The asks of a double auction do not need to hold tokens.
+			maxPrice, err := strconv.Atoi(args[2])
+			if err != nil || maxPrice < 0 {
+				return fmt.Errorf("Maximum price must be a number")
+			}
+
+			return submitAndQuery(c, args[0], c.SubmitBid(args[0], args[1], maxPrice))
+		},
+	},
+	"revealBid": {
+		args: "<auctionID> <bidID>",
+		run: func(c *auction.Client, args []string) error {
+			return submitAndQuery(c, args[0], c.RevealBid(args[0], args[1]))
+		},
+	},
+	"withdrawBid": {
+		args: "<auctionID> <bidID>",
+		run: func(c *auction.Client, args []string) error {
+			return submitAndQuery(c, args[0], c.WithdrawBid(args[0], args[1]))
+		},
+	},
+	"closeAuction": {
+		args: "<auctionID>",
+		run: func(c *auction.Client, args []string) error {
+			return submitAndQuery(c, args[0], c.CloseAuction(args[0]))
+		},
+	},
+	"endAuction": {
+		args: "<auctionID>",
+		run: func(c *auction.Client, args []string) error {
+			return submitAndQuery(c, args[0], c.EndAuction(args[0]))
+		},
+	},
+	"clearAuction": {
+		args: "<auctionID>",
+		run: func(c *auction.Client, args []string) error {
+			return submitAndQuery(c, args[0], c.ClearAuction(args[0]))
+		},
+	},
+	"cancelAuction": {
+		args: "<auctionID>",
+		run: func(c *auction.Client, args []string) error {
+			return submitAndQuery(c, args[0], c.CancelAuction(args[0]))
+		},
+	},
+	"addInvitee": {
+		args: "<auctionID> <invitee>",
+		run: func(c *auction.Client, args []string) error {
+			return submitAndQuery(c, args[0], c.AddInvitee(args[0], args[1]))
+		},
+	},
+	"queryAuction": {
+		args: "<auctionID>",
+		run: func(c *auction.Client, args []string) error {
+			return submitAndQuery(c, args[0], nil)
+		},
+	},
+	"mint": {
+		args: "<amount>",
+		run: func(c *auction.Client, args []string) error {
+			amount, err := strconv.Atoi(args[0])
+			if err != nil || amount <= 0 {
+				return fmt.Errorf("Amount must be a positive number")
+			}
+
+			return printBalance(c, c.Mint(amount))
+		},
+	},
+	"transfer": {
+		args: "<recipient> <amount>",
+		run: func(c *auction.Client, args []string) error {
+			amount, err := strconv.Atoi(args[1])
+			if err != nil || amount <= 0 {
+				return fmt.Errorf("Amount must be a positive number")
+			}
+
+			return printBalance(c, c.Transfer(args[0], amount))
+		},
+	},
+	"balance": {
+		args: "",
+		run: func(c *auction.Client, args []string) error {
+			return printBalance(c, nil)
+		},
+	},
+	"queryBid": {
+		args: "<auctionID> <bidID>",
+		run: func(c *auction.Client, args []string) error {
+			bid, err := c.QueryBid(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			return printJSON("Bid", bid)
+		},
+	},
+}
+
+func main() {
+	home, _ := os.UserHomeDir()
+
+	var cfg fabricgateway.Config
+	flag.StringVar(&cfg.WalletPath, "wallet", filepath.Join("auction-application", "src", "wallet"), "directory of the wallets of the auction application")
+	flag.StringVar(&cfg.NetworkPath, "network", filepath.Join(home, "fabric-samples", "test-network"), "directory of the Fabric test network")
+	flag.StringVar(&cfg.ChannelName, "channel", "mychannel", "channel of the auction chaincode")
+	flag.StringVar(&cfg.ChaincodeName, "chaincode", "auction-chaincode", "name of the auction chaincode")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 3 {
+		usage()
+		os.Exit(2)
+	}
+
+	name, org, user := flag.Arg(0), flag.Arg(1), flag.Arg(2)
+	args := flag.Args()[3:]
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %v\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	// Optional arguments are in brackets, and the last one can be repeated if it
+	// ends with an ellipsis.
+	required := strings.Count(cmd.args, "<")
+	repeated := strings.HasSuffix(cmd.args, "...]")
+	if len(args) < required || (!repeated && len(args) > len(strings.Fields(cmd.args))) {
+		fmt.Fprintf(os.Stderr, "Usage: auctionctl %v <org> <userID> %v\n", name, cmd.args)
+		os.Exit(2)
+	}
+
+	gateway, mspID, err := fabricgateway.Connect(cfg, org, user)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = cmd.run(auction.New(gateway, mspID), args)
+	gateway.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to run %v: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+// usage prints the commands and flags of the CLI.
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: auctionctl [flags] <command> <org> <userID> [args]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+
+	names := []string{}
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %v %v\n", name, commands[name].args)
+	}
+
+	fmt.Fprintln(os.Stderr, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// createAuction creates an auction whose deadlines are given in minutes from now.
+func createAuction(c *auction.Client, args []string) error {
+	auctionID, item, format := args[0], args[1], args[2]
+
+	biddingMinutes, err := strconv.Atoi(args[3])
+	if err != nil || biddingMinutes <= 0 {
+		return fmt.Errorf("Bidding minutes must be a positive number")
+	}
+
+	revealMinutes, err := strconv.Atoi(args[4])
+	if err != nil || revealMinutes <= 0 {
+		return fmt.Errorf("Reveal minutes must be a positive number")
+	}
+
+	deposit, err := strconv.Atoi(args[5])
+	if err != nil || deposit < 0 {
+		return fmt.Errorf("Deposit must be a number")
+	}
+
+	quantity, err := strconv.Atoi(args[6])
+	if err != nil || quantity <= 0 {
+		return fmt.Errorf("Quantity must be a positive number")
+	}
+
+	// A reserve of zero creates an auction without a reserve, followed by the
+	// invitees of an invite-only auction.
+	reserve := 0
+	if len(args) > 7 {
+		reserve, err = strconv.Atoi(args[7])
+		if err != nil || reserve < 0 {
+			return fmt.Errorf("Reserve must be a positive number, or 0 for no reserve")
+		}
+	}
+
+	invitees := []string{}
+	if len(args) > 8 {
+		invitees = args[8:]
+	}
+
+	biddingDeadline := time.Now().Add(time.Duration(biddingMinutes) * time.Minute)
+	revealDeadline := biddingDeadline.Add(time.Duration(revealMinutes) * time.Minute)
+
+	err = c.CreateAuction(auctionID, item, format, biddingDeadline, revealDeadline, deposit, quantity, reserve, invitees...)
+
+	return submitAndQuery(c, auctionID, err)
+}
+
+// createDutchAuction creates a dutch auction whose price drops every interval,
+// given in minutes, and whose deadline is given in minutes from now.
+func createDutchAuction(c *auction.Client, args []string) error {
+	auctionID, item := args[0], args[1]
+
+	startPrice, err := strconv.Atoi(args[2])
+	if err != nil || startPrice <= 0 {
+		return fmt.Errorf("Start price must be a positive number")
+	}
+
+	floorPrice, err := strconv.Atoi(args[3])
+	if err != nil || floorPrice <= 0 {
+		return fmt.Errorf("Floor price must be a positive number")
+	}
+
+	decrement, err := strconv.Atoi(args[4])
+	if err != nil || decrement <= 0 {
+		return fmt.Errorf("Decrement must be a positive number")
+	}
+
+	intervalMinutes, err := strconv.Atoi(args[5])
+	if err != nil || intervalMinutes <= 0 {
+		return fmt.Errorf("Interval minutes must be a positive number")
+	}
+
+	minutes, err := strconv.Atoi(args[6])
+	if err != nil || minutes <= 0 {
+		return fmt.Errorf("Minutes must be a positive number")
+	}
+
+	interval := time.Duration(intervalMinutes) * time.Minute
+	deadline := time.Now().Add(time.Duration(minutes) * time.Minute)
+
+	err = c.CreateDutchAuction(auctionID, item, startPrice, floorPrice, decrement, interval, deadline)
+
+	return submitAndQuery(c, auctionID, err)
+}
+
+// createEnglishAuction creates an english auction whose deadline is given in
+// minutes from now.
+func createEnglishAuction(c *auction.Client, args []string) error {
+	auctionID, item := args[0], args[1]
+
+	startPrice, err := strconv.Atoi(args[2])
+	if err != nil || startPrice <= 0 {
+		return fmt.Errorf("Start price must be a positive number")
+	}
+
+	minIncrement, err := strconv.Atoi(args[3])
+	if err != nil || minIncrement <= 0 {
+		return fmt.Errorf("Minimum increment must be a positive number")
+	}
+
+	minutes, err := strconv.Atoi(args[4])
+	if err != nil || minutes <= 0 {
+		return fmt.Errorf("Minutes must be a positive number")
+	}
+
+	deadline := time.Now().Add(time.Duration(minutes) * time.Minute)
+
+	err = c.CreateEnglishAuction(auctionID, item, startPrice, minIncrement, deadline)
+
+	return submitAndQuery(c, auctionID, err)
+}
+
+// createBid creates a bid and prints its ID, which is needed to submit, reveal
+// or withdraw it. The bid asks for a single unit unless a quantity is given.
+func createBid(c *auction.Client, args []string) error {
+	auctionID := args[0]
+
+	price, err := strconv.Atoi(args[1])
+	if err != nil || price <= 0 {
+		return fmt.Errorf("Price must be a positive number")
+	}
+
+	quantity := 1
+	if len(args) > 2 {
+		quantity, err = strconv.Atoi(args[2])
+		if err != nil || quantity <= 0 {
+			return fmt.Errorf("Quantity must be a positive number")
+		}
+	}
+
+	bidID, err := c.CreateBid(auctionID, price, quantity)
+
+	return printBid(c, auctionID, bidID, err)
+}
+
+// createDoubleAuction creates a double auction whose deadlines are given in
+// minutes from now.
+func createDoubleAuction(c *auction.Client, args []string) error {
+	auctionID, item := args[0], args[1]
+
+	biddingMinutes, err := strconv.Atoi(args[2])
+	if err != nil || biddingMinutes <= 0 {
+		return fmt.Errorf("Bidding minutes must be a positive number")
+	}
+
+	revealMinutes, err := strconv.Atoi(args[3])
+	if err != nil || revealMinutes <= 0 {
+		return fmt.Errorf("Reveal minutes must be a positive number")
+	}
+
+	deposit, err := strconv.Atoi(args[4])
+	if err != nil || deposit <= 0 {
+		return fmt.Errorf("Deposit must be a positive number")
+	}
+
+	biddingDeadline := time.Now().Add(time.Duration(biddingMinutes) * time.Minute)
+	revealDeadline := biddingDeadline.Add(time.Duration(revealMinutes) * time.Minute)
+
+	err = c.CreateDoubleAuction(auctionID, item, biddingDeadline, revealDeadline, deposit)
+
+	return submitAndQuery(c, auctionID, err)
+}
+
+// createOrder creates an order of a double auction and prints its ID, which is
+// needed to submit, reveal or withdraw it.
+func createOrder(c *auction.Client, args []string) error {
+	auctionID, side := args[0], args[1]
+	if side != "bid" && side != "ask" {
+		return fmt.Errorf("Side must be bid or ask")
+	}
+
+	price, err := strconv.Atoi(args[2])
+	if err != nil || price <= 0 {
+		return fmt.Errorf("Price must be a positive number")
+	}
+
+	quantity, err := strconv.Atoi(args[3])
+	if err != nil || quantity <= 0 {
+		return fmt.Errorf("Quantity must be a positive number")
+	}
+
+	bidID, err := c.CreateOrder(auctionID, side, price, quantity)
+
+	return printBid(c, auctionID, bidID, err)
+}
+
+// printBid prints the ID of a bid that has been created, and the bid.
+func printBid(c *auction.Client, auctionID string, bidID string, err error) error {
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("*** Result ***SAVE THIS VALUE*** BidID: %v\n", bidID)
+
+	bid, err := c.QueryBid(auctionID, bidID)
+	if err != nil {
+		return err
+	}
+
+	return printJSON("Bid", bid)
+}
+
+// submitAndQuery prints the auction once a transaction on the auction has been
+// committed.
+func submitAndQuery(c *auction.Client, auctionID string, err error) error {
+	if err != nil {
+		return err
+	}
+
+	auction, err := c.QueryAuction(auctionID)
+	if err != nil {
+		return err
+	}
+
+	return printJSON("Auction", auction)
+}
+
+// readItem prints an item of the registry.
+func readItem(c *auction.Client, itemID string) error {
+	item, err := c.ReadItem(itemID)
+	if err != nil {
+		return err
+	}
+
+	return printJSON("Item", item)
+}
+
+// printBalance prints the token balance of the client once a transaction on
+// its account has been committed.
+func printBalance(c *auction.Client, err error) error {
+	if err != nil {
+		return err
+	}
+
+	balance, err := c.ClientAccountBalance()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("*** Result: Balance: %v\n", balance)
+
+	return nil
+}
+
+// printJSON prints a result as indented JSON.
+func printJSON(name string, value interface{}) error {
+	result, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to marshal %v: %v", name, err)
+	}
+
+	fmt.Printf("*** Result: %v: %s\n", name, result)
+
+	return nil
+}
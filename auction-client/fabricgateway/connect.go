@@ -0,0 +1,151 @@
+package fabricgateway
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Organizations of the Fabric test network, with the endpoint of their peer.
+var orgs = map[string]struct {
+	mspID    string
+	endpoint string
+}{
+	"org1": {mspID: "Org1MSP", endpoint: "localhost:7051"},
+	"org2": {mspID: "Org2MSP", endpoint: "localhost:9051"},
+}
+
+// walletIdentity is an X.509 identity stored in a file system wallet by the
+// auction application.
+type walletIdentity struct {
+	Credentials struct {
+		Certificate string `json:"certificate"`
+		PrivateKey  string `json:"privateKey"`
+	} `json:"credentials"`
+	MSPID string `json:"mspId"`
+	Type  string `json:"type"`
+}
+
+// Config stores the settings used to connect to the Fabric test network.
+type Config struct {
+	// WalletPath is the directory of the wallets of the auction application.
+	WalletPath string
+	// NetworkPath is the directory of the Fabric test network.
+	NetworkPath string
+	// ChannelName is the channel of the auction chaincode.
+	ChannelName string
+	// ChaincodeName is the name of the auction chaincode.
+	ChaincodeName string
+}
+
+// Connect connects the user of an organization of the Fabric test network to
+// the gateway peer of the organization, using the identity of the user in the
+// wallet of the auction application. It returns the gateway and the MSP ID of
+// the organization. The gateway must be closed when it is no longer used.
+func Connect(cfg Config, org string, user string) (*Gateway, string, error) {
+	org = strings.ToLower(org)
+
+	orgConfig, ok := orgs[org]
+	if !ok {
+		return nil, "", fmt.Errorf("Org must be either org1 or Org1 or org2 or Org2")
+	}
+
+	id, sign, err := loadIdentity(filepath.Join(cfg.WalletPath, org, user+".id"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	peerName := "peer0." + org + ".example.com"
+	tlsCertPath := filepath.Join(cfg.NetworkPath, "organizations", "peerOrganizations", org+".example.com", "peers", peerName, "tls", "ca.crt")
+
+	tlsCertPEM, err := os.ReadFile(tlsCertPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to read TLS certificate: %v", err)
+	}
+
+	tlsCert, err := identity.CertificateFromPEM(tlsCertPEM)
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to parse TLS certificate: %v", err)
+	}
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(tlsCert)
+
+	connection, err := grpc.NewClient(orgConfig.endpoint, grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(certPool, peerName)))
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to create gRPC connection: %v", err)
+	}
+
+	fabricGateway, err := client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithClientConnection(connection),
+		client.WithEvaluateTimeout(5*time.Second),
+		client.WithEndorseTimeout(15*time.Second),
+		client.WithSubmitTimeout(5*time.Second),
+		client.WithCommitStatusTimeout(time.Minute),
+	)
+	if err != nil {
+		connection.Close()
+		return nil, "", fmt.Errorf("Failed to connect to gateway: %v", err)
+	}
+
+	contract := fabricGateway.GetNetwork(cfg.ChannelName).GetContract(cfg.ChaincodeName)
+
+	gateway := New(contract)
+	gateway.close = func() {
+		fabricGateway.Close()
+		connection.Close()
+	}
+
+	return gateway, orgConfig.mspID, nil
+}
+
+// loadIdentity reads an identity of a file system wallet.
+func loadIdentity(path string) (*identity.X509Identity, identity.Sign, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to read identity from wallet: %v", err)
+	}
+
+	var walletID walletIdentity
+
+	err = json.Unmarshal(content, &walletID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to unmarshal identity %v: %v", path, err)
+	}
+	if walletID.Type != "X.509" {
+		return nil, nil, fmt.Errorf("Identity %v has unsupported type %v", path, walletID.Type)
+	}
+
+	certificate, err := identity.CertificateFromPEM([]byte(walletID.Credentials.Certificate))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to parse certificate of identity %v: %v", path, err)
+	}
+
+	id, err := identity.NewX509Identity(walletID.MSPID, certificate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to create identity: %v", err)
+	}
+
+	privateKey, err := identity.PrivateKeyFromPEM([]byte(walletID.Credentials.PrivateKey))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to parse private key of identity %v: %v", path, err)
+	}
+
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to create signer: %v", err)
+	}
+
+	return id, sign, nil
+}
@@ -0,0 +1,48 @@
+// Package fabricgateway connects the auction client to a Fabric Gateway peer.
+// It is kept apart from the auction package so that clients can be tested with
+// a stand-in gateway, without linking the protobuf types of the Fabric Gateway
+// SDK with the ones of the chaincode.
+package fabricgateway
+
+import (
+	"auction-client/auction"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Gateway implements auction.Gateway with a contract of the Fabric Gateway SDK.
+type Gateway struct {
+	contract *client.Contract
+	close    func()
+}
+
+// New returns a gateway that sends transactions to the auction chaincode
+// through a Fabric Gateway peer.
+func New(contract *client.Contract) *Gateway {
+	return &Gateway{contract: contract}
+}
+
+// Close closes the connection of a gateway returned by Connect.
+func (g *Gateway) Close() {
+	if g.close != nil {
+		g.close()
+	}
+}
+
+// Evaluate evaluates a transaction on a peer of the gateway.
+func (g *Gateway) Evaluate(name string, args ...string) ([]byte, error) {
+	return g.contract.EvaluateTransaction(name, args...)
+}
+
+// Submit submits a transaction and waits for it to be committed.
+func (g *Gateway) Submit(tx auction.Transaction) ([]byte, error) {
+	options := []client.ProposalOption{client.WithArguments(tx.Args...)}
+	if tx.Transient != nil {
+		options = append(options, client.WithTransient(tx.Transient))
+	}
+	if len(tx.EndorsingOrgs) > 0 {
+		options = append(options, client.WithEndorsingOrganizations(tx.EndorsingOrgs...))
+	}
+
+	return g.contract.Submit(tx.Name, options...)
+}
@@ -0,0 +1,44 @@
+package fakegateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// parseArg converts a transaction argument to a parameter type.
+func parseArg(arg string, paramType reflect.Type) (reflect.Value, error) {
+	value := reflect.New(paramType).Elem()
+
+	switch paramType.Kind() {
+	case reflect.String:
+		value.SetString(arg)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(arg, 10, paramType.Bits())
+		if err != nil {
+			return value, err
+		}
+
+		value.SetInt(n)
+	case reflect.Slice:
+		err := json.Unmarshal([]byte(arg), value.Addr().Interface())
+		if err != nil {
+			return value, err
+		}
+	default:
+		return value, fmt.Errorf("unsupported parameter type %v", paramType)
+	}
+
+	return value, nil
+}
+
+// formatResult converts the result of a transaction to the bytes returned by
+// the contract API. Strings are returned as is, other values as JSON.
+func formatResult(value reflect.Value) ([]byte, error) {
+	if value.Kind() == reflect.String {
+		return []byte(value.String()), nil
+	}
+
+	return json.Marshal(value.Interface())
+}
@@ -0,0 +1,152 @@
+// Package fakegateway provides a stand-in for a Fabric Gateway peer that runs
+// the auction chaincode on an in-memory ledger. Transactions are simulated on
+// the peers of their endorsing organizations, and only committed if the
+// endorsements are valid, so clients can be tested without a Fabric network.
+package fakegateway
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"auction-chaincode/contract"
+	"auction-chaincode/fakeledger"
+	"auction-client/auction"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// metadataTransaction is the transaction of the system contract that returns
+// the metadata of the chaincode.
+const metadataTransaction = "org.hyperledger.fabric:GetMetadata"
+
+// Gateway implements auction.Gateway for a client identity.
+type Gateway struct {
+	ledger   *fakeledger.Ledger
+	contract *contract.AuctionContract
+	token    *contract.TokenContract
+	client   *fakeledger.Identity
+}
+
+// New returns a gateway to the auction chaincode on the ledger, used by the
+// given client identity. The peer of the gateway belongs to the organization of
+// the client.
+func New(ledger *fakeledger.Ledger, client *fakeledger.Identity) *Gateway {
+	token := new(contract.TokenContract)
+	token.Name = contract.TokenContractName
+
+	return &Gateway{ledger: ledger, contract: new(contract.AuctionContract), token: token, client: client}
+}
+
+// Evaluate evaluates a transaction on the peer of the gateway.
+func (g *Gateway) Evaluate(name string, args ...string) ([]byte, error) {
+	if name == metadataTransaction {
+		return g.metadata()
+	}
+
+	var result []byte
+
+	_, err := g.ledger.Execute(fakeledger.Transaction{Client: g.client, Function: name}, g.invoke(name, args, &result))
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Submit simulates a transaction on the peers of its endorsing organizations,
+// or on the peer of the gateway if they are not set, and commits it.
+func (g *Gateway) Submit(tx auction.Transaction) ([]byte, error) {
+	peers := tx.EndorsingOrgs
+	if len(peers) == 0 {
+		peers = []string{g.client.MSPID}
+	}
+
+	var result []byte
+
+	err := g.ledger.SubmitEndorsed(fakeledger.Transaction{Client: g.client, Transient: tx.Transient, Function: tx.Name}, peers, g.invoke(tx.Name, tx.Args, &result))
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// metadata returns the metadata generated by the contract API for the contracts.
+func (g *Gateway) metadata() ([]byte, error) {
+	chaincode, err := contractapi.NewChaincode(g.contract, g.token)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create chaincode: %v", err)
+	}
+
+	stub := shimtest.NewMockStub("auction-chaincode", chaincode)
+
+	response := stub.MockInvoke("metadata", [][]byte{[]byte(metadataTransaction)})
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("Failed to get metadata: %v", response.Message)
+	}
+
+	return response.Payload, nil
+}
+
+// invoke returns a function that calls a transaction of a contract with the
+// arguments converted to its parameter types, and stores its result as the
+// contract API would return it. Transactions of the token contract are prefixed
+// with its name, as in token:Transfer. As with the contract API, the before
+// transaction function of the contract is called first.
+func (g *Gateway) invoke(name string, args []string, result *[]byte) func(ctx contractapi.TransactionContextInterface) error {
+	return func(ctx contractapi.TransactionContextInterface) error {
+		var target contractapi.ContractInterface = g.contract
+		if strings.HasPrefix(name, g.token.Name+":") {
+			target = g.token
+		}
+
+		if before, ok := target.GetBeforeTransaction().(func(contractapi.TransactionContextInterface) error); ok {
+			err := before(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		method := reflect.ValueOf(target).MethodByName(strings.TrimPrefix(name, g.token.Name+":"))
+		if !method.IsValid() {
+			return fmt.Errorf("Function %v not found in contract", name)
+		}
+
+		methodType := method.Type()
+		if methodType.NumIn() != len(args)+1 {
+			return fmt.Errorf("Function %v expects %v arguments, got %v", name, methodType.NumIn()-1, len(args))
+		}
+
+		in := []reflect.Value{reflect.ValueOf(ctx)}
+		for i, arg := range args {
+			value, err := parseArg(arg, methodType.In(i+1))
+			if err != nil {
+				return fmt.Errorf("Invalid argument %v of function %v: %v", i, name, err)
+			}
+
+			in = append(in, value)
+		}
+
+		out := method.Call(in)
+
+		errValue := out[len(out)-1]
+		if !errValue.IsNil() {
+			return errValue.Interface().(error)
+		}
+
+		*result = nil
+		if len(out) == 2 {
+			response, err := formatResult(out[0])
+			if err != nil {
+				return fmt.Errorf("Failed to format result of function %v: %v", name, err)
+			}
+
+			*result = response
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,255 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// openAPIVersion is the version of the OpenAPI specification of the document.
+const openAPIVersion = "3.0.3"
+
+// pathParamPattern matches the parameters of a route path.
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// chaincodeMetadata is the part of the metadata of a chaincode, generated by
+// the contract API, that is used to generate the OpenAPI document.
+type chaincodeMetadata struct {
+	Info struct {
+		Version string `json:"version"`
+	} `json:"info"`
+	Contracts map[string]struct {
+		Name         string `json:"name"`
+		Default      bool   `json:"default"`
+		Transactions []struct {
+			Name string `json:"name"`
+		} `json:"transactions"`
+	} `json:"contracts"`
+	Components struct {
+		Schemas map[string]interface{} `json:"schemas"`
+	} `json:"components"`
+}
+
+// OpenAPI generates the OpenAPI document of the service from the metadata of
+// the auction chaincode. The schemas of the chaincode objects are taken from the
+// metadata, and every route must map to transactions of the chaincode.
+// Transactions of contracts other than the default one are prefixed with the
+// name of their contract.
+func OpenAPI(metadata []byte) ([]byte, error) {
+	var cc chaincodeMetadata
+
+	err := json.Unmarshal(metadata, &cc)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal chaincode metadata: %v", err)
+	}
+
+	transactions := map[string]bool{}
+	for _, contract := range cc.Contracts {
+		prefix := contract.Name + ":"
+		if contract.Default {
+			prefix = ""
+		}
+
+		for _, tx := range contract.Transactions {
+			transactions[prefix+tx.Name] = true
+		}
+	}
+
+	// The references of the chaincode schemas are relative to the components.
+	schemas := map[string]interface{}{}
+	for name, schema := range cc.Components.Schemas {
+		schemas[name] = componentSchema(schema)
+	}
+	for _, body := range []interface{}{CreateAuctionRequest{}, CreateDutchAuctionRequest{}, CreateEnglishAuctionRequest{}, CreateDoubleAuctionRequest{}, InviteeRequest{}, OpenBidRequest{}, CreateBidRequest{}, CreateBidResponse{}, RegisterItemRequest{}, MintRequest{}, TransferRequest{}, AccountResponse{}, ErrorResponse{}} {
+		schemas[reflect.TypeOf(body).Name()] = structSchema(reflect.TypeOf(body))
+	}
+
+	paths := map[string]map[string]interface{}{}
+	for _, rt := range append(routes, openAPIRoute) {
+		for _, tx := range rt.Transactions {
+			if !transactions[tx] {
+				return nil, fmt.Errorf("Transaction %v of %v %v not found in chaincode metadata", tx, rt.Method, rt.Path)
+			}
+		}
+
+		operation, err := routeOperation(rt, schemas)
+		if err != nil {
+			return nil, err
+		}
+
+		if paths[rt.Path] == nil {
+			paths[rt.Path] = map[string]interface{}{}
+		}
+		paths[rt.Path][strings.ToLower(rt.Method)] = operation
+	}
+
+	document := map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":       "Auction REST gateway",
+			"description": "REST resources of the auction chaincode.",
+			"version":     cc.Info.Version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []interface{}{map[string]interface{}{"bearerAuth": []string{}}},
+	}
+
+	return json.MarshalIndent(document, "", "  ")
+}
+
+// routeOperation returns the OpenAPI operation of a route.
+func routeOperation(rt route, schemas map[string]interface{}) (map[string]interface{}, error) {
+	parameters := []interface{}{
+		map[string]interface{}{
+			"name":        IdentityHeader,
+			"in":          "header",
+			"required":    false,
+			"description": "Identity of the request, as <org>/<user>, which must be the identity of the bearer token",
+			"schema":      map[string]interface{}{"type": "string"},
+		},
+	}
+	for _, match := range pathParamPattern.FindAllStringSubmatch(rt.Path, -1) {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, param := range rt.Query {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        param.Name,
+			"in":          "query",
+			"description": param.Description,
+			"schema":      map[string]interface{}{"type": param.Type},
+		})
+	}
+
+	response, err := responseSchema(rt.Response, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid response of %v %v: %v", rt.Method, rt.Path, err)
+	}
+
+	operation := map[string]interface{}{
+		"summary":    rt.Summary,
+		"parameters": parameters,
+		"responses": map[string]interface{}{
+			fmt.Sprint(rt.Status): map[string]interface{}{
+				"description": rt.Summary,
+				"content":     jsonContent(response),
+			},
+			"default": map[string]interface{}{
+				"description": "Error",
+				"content":     jsonContent(schemaRef("ErrorResponse")),
+			},
+		},
+	}
+	if len(rt.Transactions) > 0 {
+		operation["description"] = fmt.Sprintf("Runs the %v transactions of the auction chaincode.", strings.Join(rt.Transactions, ", "))
+	}
+	if rt.Request != nil {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  jsonContent(schemaRef(reflect.TypeOf(rt.Request).Name())),
+		}
+	}
+
+	return operation, nil
+}
+
+// responseSchema returns the schema of a response. Arrays are prefixed with [].
+func responseSchema(name string, schemas map[string]interface{}) (interface{}, error) {
+	if name == openAPISchema {
+		return map[string]interface{}{"type": "object"}, nil
+	}
+
+	itemName := strings.TrimPrefix(name, "[]")
+	if _, ok := schemas[itemName]; !ok {
+		return nil, fmt.Errorf("schema %v not found in chaincode metadata", itemName)
+	}
+
+	if itemName != name {
+		return map[string]interface{}{"type": "array", "items": schemaRef(itemName)}, nil
+	}
+
+	return schemaRef(name), nil
+}
+
+// componentSchema converts a schema of the chaincode metadata to an OpenAPI
+// schema. References are made relative to the components of the document.
+func componentSchema(schema interface{}) interface{} {
+	switch value := schema.(type) {
+	case map[string]interface{}:
+		converted := map[string]interface{}{}
+		for key, v := range value {
+			switch key {
+			case "$id":
+				continue
+			case "$ref":
+				converted[key] = "#/components/schemas/" + fmt.Sprint(v)
+			default:
+				converted[key] = componentSchema(v)
+			}
+		}
+
+		return converted
+	case []interface{}:
+		converted := []interface{}{}
+		for _, v := range value {
+			converted = append(converted, componentSchema(v))
+		}
+
+		return converted
+	}
+
+	return schema
+}
+
+// structSchema returns the schema of a request or response body of the service.
+func structSchema(structType reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")
+
+		switch field.Type.Kind() {
+		case reflect.String:
+			properties[tag[0]] = map[string]interface{}{"type": "string"}
+		case reflect.Slice:
+			properties[tag[0]] = map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+		default:
+			properties[tag[0]] = map[string]interface{}{"type": "integer", "format": fmt.Sprintf("int%d", field.Type.Bits())}
+		}
+
+		if len(tag) == 1 {
+			required = append(required, tag[0])
+		}
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// schemaRef returns a reference to a schema of the components of the document.
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// jsonContent returns the JSON content of a request or response.
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
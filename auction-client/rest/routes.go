@@ -0,0 +1,689 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"auction-client/auction"
+)
+
+// CreateAuctionRequest is the body of a request to create an auction. The
+// deadlines are given in seconds since the Unix epoch. The optional deposit is
+// posted by every bid, and the optional reserve is only revealed to the
+// chaincode as transient data. The auction sells a single unit of the item if
+// the quantity is omitted. If invitees are given, as MSP IDs of organizations
+// or IDs of client identities, only they can bid on the auction.
+type CreateAuctionRequest struct {
+	ID              string   `json:"id"`
+	Item            string   `json:"item"`
+	Format          string   `json:"format"`
+	BiddingDeadline int64    `json:"biddingDeadline"`
+	RevealDeadline  int64    `json:"revealDeadline"`
+	Deposit         int      `json:"deposit,omitempty"`
+	Quantity        int      `json:"quantity,omitempty"`
+	Reserve         int      `json:"reserve,omitempty"`
+	Invitees        []string `json:"invitees,omitempty"`
+}
+
+// CreateDutchAuctionRequest is the body of a request to create a dutch auction.
+// The price drops by the decrement every interval, given in seconds, and the
+// deadline is given in seconds since the Unix epoch.
+type CreateDutchAuctionRequest struct {
+	ID         string `json:"id"`
+	Item       string `json:"item"`
+	StartPrice int    `json:"startPrice"`
+	FloorPrice int    `json:"floorPrice"`
+	Decrement  int    `json:"decrement"`
+	Interval   int64  `json:"interval"`
+	Deadline   int64  `json:"deadline"`
+}
+
+// CreateEnglishAuctionRequest is the body of a request to create an english
+// auction. The deadline is given in seconds since the Unix epoch.
+type CreateEnglishAuctionRequest struct {
+	ID           string `json:"id"`
+	Item         string `json:"item"`
+	StartPrice   int    `json:"startPrice"`
+	MinIncrement int    `json:"minIncrement"`
+	Deadline     int64  `json:"deadline"`
+}
+
+// CreateDoubleAuctionRequest is the body of a request to create a double
+// auction. The deadlines are given in seconds since the Unix epoch, and the
+// deposit posted by every order must be positive.
+type CreateDoubleAuctionRequest struct {
+	ID              string `json:"id"`
+	Item            string `json:"item"`
+	BiddingDeadline int64  `json:"biddingDeadline"`
+	RevealDeadline  int64  `json:"revealDeadline"`
+	Deposit         int    `json:"deposit"`
+}
+
+// InviteeRequest is the body of a request to invite an organization, given by
+// its MSP ID, or a client identity, given by its ID, to an invite-only auction.
+type InviteeRequest struct {
+	Invitee string `json:"invitee"`
+}
+
+// OpenBidRequest is the body of a request to place a public bid on an english
+// auction.
+type OpenBidRequest struct {
+	Price int `json:"price"`
+}
+
+// CreateBidRequest is the body of a request to bid on an auction. The bid is
+// passed to the chaincode as transient data. The price is the price of one
+// unit, and the bid asks for a single unit if the quantity is omitted. The
+// maximum price is public, and is held from the token account of the bidder
+// until the auction ends. The orders of a double auction have a bid or ask
+// side, and asks do not need a maximum price.
+type CreateBidRequest struct {
+	Price    int    `json:"price"`
+	Quantity int    `json:"quantity,omitempty"`
+	Side     string `json:"side,omitempty"`
+	MaxPrice int    `json:"maxPrice"`
+}
+
+// CreateBidResponse is the body of the response to a bid. The bid ID is needed
+// to reveal or withdraw the bid.
+type CreateBidResponse struct {
+	BidID string `json:"bidID"`
+}
+
+// RegisterItemRequest is the body of a request to register an item.
+type RegisterItemRequest struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+// MintRequest is the body of a request to mint tokens.
+type MintRequest struct {
+	Amount int `json:"amount"`
+}
+
+// TransferRequest is the body of a request to transfer tokens to the account of
+// a client identity.
+type TransferRequest struct {
+	Recipient string `json:"recipient"`
+	Amount    int    `json:"amount"`
+}
+
+// AccountResponse is the token account of the identity of a request.
+type AccountResponse struct {
+	ID      string `json:"id"`
+	Balance int    `json:"balance"`
+}
+
+// queryParam is a query parameter of a route.
+type queryParam struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// route maps a REST resource to the transactions of the auction chaincode. The
+// routes are used both to serve requests and to generate the OpenAPI document.
+type route struct {
+	Method       string
+	Path         string
+	Summary      string
+	Transactions []string
+	Query        []queryParam
+	Request      interface{}
+	Response     string
+	Status       int
+	handle       func(c *auction.Client, r *http.Request) (interface{}, error)
+}
+
+// Names of the response schemas that are not components of the contract metadata.
+const (
+	openAPISchema    = "OpenAPI"
+	bidCreatedSchema = "CreateBidResponse"
+	accountSchema    = "AccountResponse"
+)
+
+var routes = []route{
+	{
+		Method:       http.MethodPost,
+		Path:         "/items",
+		Summary:      "Register an item owned by the identity",
+		Transactions: []string{"RegisterItem"},
+		Request:      RegisterItemRequest{},
+		Response:     "Item",
+		Status:       http.StatusCreated,
+		handle:       registerItem,
+	},
+	{
+		Method:       http.MethodGet,
+		Path:         "/items/{id}",
+		Summary:      "Get an item and its owner",
+		Transactions: []string{"ReadItem"},
+		Response:     "Item",
+		Status:       http.StatusOK,
+		handle: func(c *auction.Client, r *http.Request) (interface{}, error) {
+			return c.ReadItem(r.PathValue("id"))
+		},
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/auctions",
+		Summary:      "Create an auction",
+		Transactions: []string{"CreateAuction"},
+		Request:      CreateAuctionRequest{},
+		Response:     "Auction",
+		Status:       http.StatusCreated,
+		handle:       createAuction,
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/dutch-auctions",
+		Summary:      "Create a dutch auction",
+		Transactions: []string{"CreateDutchAuction"},
+		Request:      CreateDutchAuctionRequest{},
+		Response:     "Auction",
+		Status:       http.StatusCreated,
+		handle:       createDutchAuction,
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/english-auctions",
+		Summary:      "Create an english auction",
+		Transactions: []string{"CreateEnglishAuction"},
+		Request:      CreateEnglishAuctionRequest{},
+		Response:     "Auction",
+		Status:       http.StatusCreated,
+		handle:       createEnglishAuction,
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/double-auctions",
+		Summary:      "Create a double auction",
+		Transactions: []string{"CreateDoubleAuction"},
+		Request:      CreateDoubleAuctionRequest{},
+		Response:     "Auction",
+		Status:       http.StatusCreated,
+		handle:       createDoubleAuction,
+	},
+	{
+		Method:       http.MethodGet,
+		Path:         "/auctions",
+		Summary:      "List auctions by status, seller or item",
+		Transactions: []string{"ListAuctionsByStatus", "ListAuctionsBySeller", "ListAuctionsByItem", "SearchAuctions"},
+		Query: []queryParam{
+			{Name: "status", Type: "string", Description: "Status of the auctions"},
+			{Name: "seller", Type: "string", Description: "Seller of the auctions"},
+			{Name: "item", Type: "string", Description: "Item sold by the auctions"},
+			{Name: "pageSize", Type: "integer", Description: "Number of auctions in the page, 10 by default and at most 50"},
+			{Name: "bookmark", Type: "string", Description: "Bookmark of the page, returned with the previous page"},
+		},
+		Response: "AuctionQueryResult",
+		Status:   http.StatusOK,
+		handle:   listAuctions,
+	},
+	{
+		Method:       http.MethodGet,
+		Path:         "/auctions/{id}",
+		Summary:      "Get an auction",
+		Transactions: []string{"QueryAuction"},
+		Response:     "Auction",
+		Status:       http.StatusOK,
+		handle: func(c *auction.Client, r *http.Request) (interface{}, error) {
+			return c.QueryAuction(r.PathValue("id"))
+		},
+	},
+	{
+		Method:       http.MethodGet,
+		Path:         "/auctions/{id}/history",
+		Summary:      "Get the history of an auction",
+		Transactions: []string{"GetAuctionHistory"},
+		Response:     "[]AuctionHistoryEntry",
+		Status:       http.StatusOK,
+		handle: func(c *auction.Client, r *http.Request) (interface{}, error) {
+			return c.GetAuctionHistory(r.PathValue("id"))
+		},
+	},
+	{
+		Method:       http.MethodGet,
+		Path:         "/auctions/{id}/reserve",
+		Summary:      "Get the reserve of an auction of the seller",
+		Transactions: []string{"QueryReserve"},
+		Response:     "Reserve",
+		Status:       http.StatusOK,
+		handle: func(c *auction.Client, r *http.Request) (interface{}, error) {
+			return c.QueryReserve(r.PathValue("id"))
+		},
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/auctions/{id}/close",
+		Summary:      "Close an auction to new bids",
+		Transactions: []string{"CloseAuction"},
+		Response:     "Auction",
+		Status:       http.StatusOK,
+		handle: func(c *auction.Client, r *http.Request) (interface{}, error) {
+			return queryAfter(c, r.PathValue("id"), c.CloseAuction(r.PathValue("id")))
+		},
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/auctions/{id}/end",
+		Summary:      "End an auction, revealing its reserve for the seller or an auctioneer",
+		Transactions: []string{"QueryStoredReserve", "EndAuction"},
+		Response:     "Auction",
+		Status:       http.StatusOK,
+		handle: func(c *auction.Client, r *http.Request) (interface{}, error) {
+			return queryAfter(c, r.PathValue("id"), c.EndAuction(r.PathValue("id")))
+		},
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/auctions/{id}/clear",
+		Summary:      "Clear a double auction, matching its revealed orders",
+		Transactions: []string{"ClearAuction"},
+		Response:     "Auction",
+		Status:       http.StatusOK,
+		handle: func(c *auction.Client, r *http.Request) (interface{}, error) {
+			return queryAfter(c, r.PathValue("id"), c.ClearAuction(r.PathValue("id")))
+		},
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/auctions/{id}/cancel",
+		Summary:      "Cancel an auction",
+		Transactions: []string{"CancelAuction"},
+		Response:     "Auction",
+		Status:       http.StatusOK,
+		handle: func(c *auction.Client, r *http.Request) (interface{}, error) {
+			return queryAfter(c, r.PathValue("id"), c.CancelAuction(r.PathValue("id")))
+		},
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/auctions/{id}/invitees",
+		Summary:      "Invite an organization or a client identity to an invite-only auction",
+		Transactions: []string{"AddInvitee"},
+		Request:      InviteeRequest{},
+		Response:     "Auction",
+		Status:       http.StatusOK,
+		handle:       addInvitee,
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/auctions/{id}/accept",
+		Summary:      "Accept the current price of a dutch auction",
+		Transactions: []string{"AcceptPrice"},
+		Response:     "Auction",
+		Status:       http.StatusOK,
+		handle: func(c *auction.Client, r *http.Request) (interface{}, error) {
+			return queryAfter(c, r.PathValue("id"), c.AcceptPrice(r.PathValue("id")))
+		},
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/auctions/{id}/open-bids",
+		Summary:      "Place a public bid on an english auction",
+		Transactions: []string{"PlaceOpenBid"},
+		Request:      OpenBidRequest{},
+		Response:     "Auction",
+		Status:       http.StatusOK,
+		handle:       placeOpenBid,
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/auctions/{id}/bids",
+		Summary:      "Create a bid and submit it to an auction",
+		Transactions: []string{"CreateBid", "SubmitBid"},
+		Request:      CreateBidRequest{},
+		Response:     bidCreatedSchema,
+		Status:       http.StatusCreated,
+		handle:       createBid,
+	},
+	{
+		Method:       http.MethodGet,
+		Path:         "/auctions/{id}/bids/{bidID}",
+		Summary:      "Get a bid of the bidder",
+		Transactions: []string{"QueryBid"},
+		Response:     "FullBid",
+		Status:       http.StatusOK,
+		handle: func(c *auction.Client, r *http.Request) (interface{}, error) {
+			return c.QueryBid(r.PathValue("id"), r.PathValue("bidID"))
+		},
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/auctions/{id}/bids/{bidID}/reveal",
+		Summary:      "Reveal a bid once the auction is closed",
+		Transactions: []string{"QueryStoredBid", "RevealBid"},
+		Response:     "Auction",
+		Status:       http.StatusOK,
+		handle: func(c *auction.Client, r *http.Request) (interface{}, error) {
+			return queryAfter(c, r.PathValue("id"), c.RevealBid(r.PathValue("id"), r.PathValue("bidID")))
+		},
+	},
+	{
+		Method:       http.MethodDelete,
+		Path:         "/auctions/{id}/bids/{bidID}",
+		Summary:      "Withdraw a bid while the auction is open",
+		Transactions: []string{"QueryStoredBid", "WithdrawBid"},
+		Response:     "Auction",
+		Status:       http.StatusOK,
+		handle: func(c *auction.Client, r *http.Request) (interface{}, error) {
+			return queryAfter(c, r.PathValue("id"), c.WithdrawBid(r.PathValue("id"), r.PathValue("bidID")))
+		},
+	},
+	{
+		Method:       http.MethodGet,
+		Path:         "/account",
+		Summary:      "Get the token account of the identity",
+		Transactions: []string{"GetSubmittingClientIdentity", "token:ClientAccountBalance"},
+		Response:     accountSchema,
+		Status:       http.StatusOK,
+		handle: func(c *auction.Client, r *http.Request) (interface{}, error) {
+			return account(c, nil)
+		},
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/account/mint",
+		Summary:      "Mint tokens in the account of the identity",
+		Transactions: []string{"token:Mint"},
+		Request:      MintRequest{},
+		Response:     accountSchema,
+		Status:       http.StatusOK,
+		handle:       mint,
+	},
+	{
+		Method:       http.MethodPost,
+		Path:         "/account/transfer",
+		Summary:      "Transfer tokens to the account of another identity",
+		Transactions: []string{"token:Transfer"},
+		Request:      TransferRequest{},
+		Response:     accountSchema,
+		Status:       http.StatusOK,
+		handle:       transfer,
+	},
+}
+
+// openAPIRoute serves the OpenAPI document, generated from the metadata of the
+// deployed chaincode. It is kept out of the routes, which are used to generate
+// the document.
+var openAPIRoute = route{
+	Method:   http.MethodGet,
+	Path:     "/openapi.json",
+	Summary:  "Get the OpenAPI document of the service",
+	Response: openAPISchema,
+	Status:   http.StatusOK,
+}
+
+// serveOpenAPI returns the OpenAPI document of the service.
+func serveOpenAPI(c *auction.Client, r *http.Request) (interface{}, error) {
+	metadata, err := c.GetMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := OpenAPI(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.RawMessage(document), nil
+}
+
+// registerItem registers the item of the request body.
+func registerItem(c *auction.Client, r *http.Request) (interface{}, error) {
+	var body RegisterItemRequest
+
+	err := decodeBody(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	if body.ID == "" {
+		return nil, badRequest("Item id is required")
+	}
+
+	err = c.RegisterItem(body.ID, body.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ReadItem(body.ID)
+}
+
+// createAuction creates the auction of the request body.
+func createAuction(c *auction.Client, r *http.Request) (interface{}, error) {
+	var body CreateAuctionRequest
+
+	err := decodeBody(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	if body.ID == "" || body.Item == "" {
+		return nil, badRequest("Auction id and item are required")
+	}
+
+	if body.Quantity == 0 {
+		body.Quantity = 1
+	}
+
+	err = c.CreateAuction(body.ID, body.Item, body.Format, time.Unix(body.BiddingDeadline, 0), time.Unix(body.RevealDeadline, 0), body.Deposit, body.Quantity, body.Reserve, body.Invitees...)
+
+	return queryAfter(c, body.ID, err)
+}
+
+// createDutchAuction creates the dutch auction of the request body.
+func createDutchAuction(c *auction.Client, r *http.Request) (interface{}, error) {
+	var body CreateDutchAuctionRequest
+
+	err := decodeBody(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	if body.ID == "" || body.Item == "" {
+		return nil, badRequest("Auction id and item are required")
+	}
+
+	err = c.CreateDutchAuction(body.ID, body.Item, body.StartPrice, body.FloorPrice, body.Decrement, time.Duration(body.Interval)*time.Second, time.Unix(body.Deadline, 0))
+
+	return queryAfter(c, body.ID, err)
+}
+
+// createEnglishAuction creates the english auction of the request body.
+func createEnglishAuction(c *auction.Client, r *http.Request) (interface{}, error) {
+	var body CreateEnglishAuctionRequest
+
+	err := decodeBody(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	if body.ID == "" || body.Item == "" {
+		return nil, badRequest("Auction id and item are required")
+	}
+
+	err = c.CreateEnglishAuction(body.ID, body.Item, body.StartPrice, body.MinIncrement, time.Unix(body.Deadline, 0))
+
+	return queryAfter(c, body.ID, err)
+}
+
+// createDoubleAuction creates the double auction of the request body.
+func createDoubleAuction(c *auction.Client, r *http.Request) (interface{}, error) {
+	var body CreateDoubleAuctionRequest
+
+	err := decodeBody(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	if body.ID == "" || body.Item == "" {
+		return nil, badRequest("Auction id and item are required")
+	}
+
+	err = c.CreateDoubleAuction(body.ID, body.Item, time.Unix(body.BiddingDeadline, 0), time.Unix(body.RevealDeadline, 0), body.Deposit)
+
+	return queryAfter(c, body.ID, err)
+}
+
+// addInvitee adds the invitee of the request body to the auction.
+func addInvitee(c *auction.Client, r *http.Request) (interface{}, error) {
+	var body InviteeRequest
+
+	err := decodeBody(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	if body.Invitee == "" {
+		return nil, badRequest("Invitee is required")
+	}
+
+	auctionID := r.PathValue("id")
+
+	return queryAfter(c, auctionID, c.AddInvitee(auctionID, body.Invitee))
+}
+
+// placeOpenBid places the public bid of the request body.
+func placeOpenBid(c *auction.Client, r *http.Request) (interface{}, error) {
+	var body OpenBidRequest
+
+	err := decodeBody(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	if body.Price <= 0 {
+		return nil, badRequest("Bid price must be positive")
+	}
+
+	auctionID := r.PathValue("id")
+
+	return queryAfter(c, auctionID, c.PlaceOpenBid(auctionID, body.Price))
+}
+
+// createBid creates the bid of the request body and submits it to the auction.
+func createBid(c *auction.Client, r *http.Request) (interface{}, error) {
+	var body CreateBidRequest
+
+	err := decodeBody(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	if body.Price <= 0 {
+		return nil, badRequest("Bid price must be positive, got %v", body.Price)
+	}
+	if body.Quantity == 0 {
+		body.Quantity = 1
+	}
+	if body.Quantity < 0 {
+		return nil, badRequest("Bid quantity must be positive, got %v", body.Quantity)
+	}
+	if body.Side != "" && body.Side != "bid" && body.Side != "ask" {
+		return nil, badRequest("Side must be bid or ask, got %v", body.Side)
+	}
+	if body.Side != "ask" && body.MaxPrice/body.Quantity < body.Price {
+		return nil, badRequest("Maximum price %v must not be lower than the price %v of %v units", body.MaxPrice, body.Price, body.Quantity)
+	}
+	if body.MaxPrice < 0 {
+		return nil, badRequest("Maximum price must not be negative, got %v", body.MaxPrice)
+	}
+
+	auctionID := r.PathValue("id")
+
+	bidID, err := c.CreateOrder(auctionID, body.Side, body.Price, body.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.SubmitBid(auctionID, bidID, body.MaxPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateBidResponse{BidID: bidID}, nil
+}
+
+// listAuctions lists the auctions matching the query parameters. A single filter
+// uses the index of the filter, other combinations need a CouchDB state database.
+func listAuctions(c *auction.Client, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+
+	pageSize := int32(10)
+	if value := query.Get("pageSize"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || n <= 0 {
+			return nil, badRequest("Page size must be a positive number, got %v", value)
+		}
+		pageSize = int32(n)
+	}
+
+	status, seller, item, bookmark := query.Get("status"), query.Get("seller"), query.Get("item"), query.Get("bookmark")
+
+	switch {
+	case status != "" && seller == "" && item == "":
+		return c.ListAuctionsByStatus(status, pageSize, bookmark)
+	case seller != "" && status == "" && item == "":
+		return c.ListAuctionsBySeller(seller, pageSize, bookmark)
+	case item != "" && status == "" && seller == "":
+		return c.ListAuctionsByItem(item, pageSize, bookmark)
+	}
+
+	return c.SearchAuctions(status, seller, item, pageSize, bookmark)
+}
+
+// mint mints the tokens of the request body.
+func mint(c *auction.Client, r *http.Request) (interface{}, error) {
+	var body MintRequest
+
+	err := decodeBody(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	if body.Amount <= 0 {
+		return nil, badRequest("Amount must be positive, got %v", body.Amount)
+	}
+
+	return account(c, c.Mint(body.Amount))
+}
+
+// transfer transfers the tokens of the request body.
+func transfer(c *auction.Client, r *http.Request) (interface{}, error) {
+	var body TransferRequest
+
+	err := decodeBody(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	if body.Recipient == "" || body.Amount <= 0 {
+		return nil, badRequest("Recipient and a positive amount are required")
+	}
+
+	return account(c, c.Transfer(body.Recipient, body.Amount))
+}
+
+// account returns the token account of the identity once a transaction on the
+// account has been committed.
+func account(c *auction.Client, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := c.GetSubmittingClientIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := c.ClientAccountBalance()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccountResponse{ID: id, Balance: balance}, nil
+}
+
+// queryAfter returns the auction once a transaction on the auction has been
+// committed.
+func queryAfter(c *auction.Client, auctionID string, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return c.QueryAuction(auctionID)
+}
@@ -0,0 +1,178 @@
+// Package rest exposes the auctions and bids of the auction chaincode as REST
+// resources. Every request is authenticated by a bearer token issued for a
+// single identity, and the server submits the transactions of the request
+// through the gateway of that identity. A request can also name the identity it
+// runs as, which must be the identity of its token.
+// Bids are sent in the request body and passed to the chaincode as transient
+// data, so their prices never appear in the arguments of a transaction.
+package rest
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"auction-client/auction"
+)
+
+// IdentityHeader is the request header that names the identity of a request, as
+// <org>/<user>.
+const IdentityHeader = "X-Auction-Identity"
+
+// bearerPrefix is the prefix of the token in the Authorization header.
+const bearerPrefix = "Bearer "
+
+// Authenticator returns the identity that the caller of a request is allowed to
+// act as, as <org>/<user>.
+type Authenticator interface {
+	Authenticate(r *http.Request) (string, error)
+}
+
+// TokenAuthenticator authenticates the callers of the service by the bearer
+// token of the Authorization header of their requests. It maps each token to the
+// identity it was issued for.
+type TokenAuthenticator map[string]string
+
+// Authenticate returns the identity of the bearer token of a request.
+func (a TokenAuthenticator) Authenticate(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return "", fmt.Errorf("Missing bearer token in Authorization header")
+	}
+	token := strings.TrimPrefix(header, bearerPrefix)
+
+	// Compare every token in constant time, so that the time taken does not
+	// reveal how much of a token was guessed.
+	identity := ""
+	for t, id := range a {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			identity = id
+		}
+	}
+	if identity == "" {
+		return "", fmt.Errorf("Invalid bearer token")
+	}
+
+	return identity, nil
+}
+
+// Submitter returns the gateway that evaluates and submits the transactions of
+// an identity, and the MSP ID of the organization of the identity.
+type Submitter interface {
+	Gateway(identity string) (auction.Gateway, string, error)
+}
+
+// Server serves the REST resources of the auction chaincode.
+type Server struct {
+	authenticator Authenticator
+	submitter     Submitter
+	mux           *http.ServeMux
+}
+
+// NewServer returns a server that authenticates requests with the
+// authenticator, and submits transactions with the submitter.
+func NewServer(authenticator Authenticator, submitter Submitter) *Server {
+	s := &Server{authenticator: authenticator, submitter: submitter, mux: http.NewServeMux()}
+
+	for _, r := range routes {
+		s.mux.HandleFunc(r.Method+" "+r.Path, s.handler(r))
+	}
+
+	openAPI := openAPIRoute
+	openAPI.handle = serveOpenAPI
+	s.mux.HandleFunc(openAPI.Method+" "+openAPI.Path, s.handler(openAPI))
+
+	return s
+}
+
+// ServeHTTP serves a request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handler returns the handler of a route, which runs the route with the
+// auction client of the identity of the request.
+func (s *Server) handler(rt route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, err := s.authenticator.Authenticate(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		// A caller can only act as the identity it was authenticated as.
+		if requested := r.Header.Get(IdentityHeader); requested != "" && requested != identity {
+			writeError(w, http.StatusForbidden, fmt.Errorf("Permission denied, cannot act as identity %v", requested))
+			return
+		}
+
+		gateway, mspID, err := s.submitter.Gateway(identity)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("Failed to get gateway of identity %v: %v", identity, err))
+			return
+		}
+
+		result, err := rt.handle(auction.New(gateway, mspID), r)
+		if err != nil {
+			var reqErr *requestError
+			if errors.As(err, &reqErr) {
+				writeError(w, http.StatusBadRequest, err)
+			} else {
+				writeError(w, http.StatusBadGateway, err)
+			}
+			return
+		}
+
+		writeJSON(w, rt.Status, result)
+	}
+}
+
+// requestError is an error in the parameters or the body of a request.
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+// badRequest returns a request error.
+func badRequest(format string, args ...interface{}) error {
+	return &requestError{err: fmt.Errorf(format, args...)}
+}
+
+// ErrorResponse is the body of a failed request.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeError writes the error of a failed request.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+// writeJSON writes the JSON body of a response.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if body != nil {
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+// decodeBody decodes the JSON body of a request.
+func decodeBody(r *http.Request, body interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(body)
+	if err != nil {
+		return badRequest("Invalid request body: %v", err)
+	}
+
+	return nil
+}
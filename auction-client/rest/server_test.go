@@ -0,0 +1,248 @@
+package rest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auction-chaincode/fakeledger"
+	"auction-client/auction"
+	"auction-client/fakegateway"
+	"auction-client/rest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// submitter returns fake gateways for the identities of the test network, which
+// can both sell and bid.
+type submitter struct {
+	ledger *fakeledger.Ledger
+}
+
+var mspIDs = map[string]string{"org1": "Org1MSP", "org2": "Org2MSP"}
+
+func (s *submitter) Gateway(identity string) (auction.Gateway, string, error) {
+	org, user, ok := strings.Cut(identity, "/")
+	if !ok || mspIDs[org] == "" {
+		return nil, "", fmt.Errorf("unknown identity %v", identity)
+	}
+
+	return fakegateway.New(s.ledger, fakeledger.NewIdentity(mspIDs[org], user, "auction.role", "seller,bidder")), mspIDs[org], nil
+}
+
+// tokens issues a bearer token to each identity of the tests.
+var tokens = rest.TokenAuthenticator{}
+
+func init() {
+	for _, identity := range []string{"org1/seller", "org1/minter", "org1/bidder", "org1/bidder1", "org2/bidder2"} {
+		tokens["token-"+identity] = identity
+	}
+}
+
+// request sends a request as an identity, with its bearer token.
+func request(t *testing.T, server http.Handler, identity string, method string, path string, body interface{}, result interface{}) int {
+	var reqBody bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&reqBody).Encode(body))
+	}
+
+	req := httptest.NewRequest(method, path, &reqBody)
+	if identity != "" {
+		req.Header.Set("Authorization", "Bearer token-"+identity)
+		req.Header.Set(rest.IdentityHeader, identity)
+	}
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	if result != nil {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), result), rec.Body.String())
+	}
+
+	return rec.Code
+}
+
+// fund mints tokens and transfers them to the account of an identity.
+func fund(t *testing.T, server http.Handler, identity string, amount int) {
+	var recipient rest.AccountResponse
+	code := request(t, server, identity, http.MethodGet, "/account", nil, &recipient)
+	require.Equal(t, http.StatusOK, code)
+
+	var minter rest.AccountResponse
+	code = request(t, server, "org1/minter", http.MethodPost, "/account/mint", rest.MintRequest{Amount: amount}, &minter)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, amount, minter.Balance)
+
+	code = request(t, server, "org1/minter", http.MethodPost, "/account/transfer", rest.TransferRequest{Recipient: recipient.ID, Amount: amount}, &minter)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, 0, minter.Balance)
+}
+
+func TestAuctionResources(t *testing.T) {
+	ledger := fakeledger.New()
+	server := rest.NewServer(tokens, &submitter{ledger: ledger})
+	fund(t, server, "org1/bidder1", 1000)
+	fund(t, server, "org2/bidder2", 1000)
+
+	var item auction.Item
+	code := request(t, server, "org1/seller", http.MethodPost, "/items", rest.RegisterItemRequest{ID: "painting", Description: "Oil on canvas"}, &item)
+	require.Equal(t, http.StatusCreated, code)
+	require.Equal(t, "Org1MSP", item.OwnerOrg)
+
+	var created auction.Auction
+	code = request(t, server, "org1/seller", http.MethodPost, "/auctions", rest.CreateAuctionRequest{
+		ID:              "1",
+		Item:            "painting",
+		Format:          "second-price",
+		BiddingDeadline: ledger.Clock.Add(time.Hour).Unix(),
+		RevealDeadline:  ledger.Clock.Add(2 * time.Hour).Unix(),
+		Deposit:         10,
+		Reserve:         150,
+	}, &created)
+	require.Equal(t, http.StatusCreated, code)
+	require.Equal(t, "open", created.Status)
+	require.NotEmpty(t, created.ReserveHash)
+
+	var bid1, bid2 rest.CreateBidResponse
+	code = request(t, server, "org1/bidder1", http.MethodPost, "/auctions/1/bids", rest.CreateBidRequest{Price: 100, MaxPrice: 500}, &bid1)
+	require.Equal(t, http.StatusCreated, code)
+	code = request(t, server, "org2/bidder2", http.MethodPost, "/auctions/1/bids", rest.CreateBidRequest{Price: 300, MaxPrice: 500}, &bid2)
+	require.Equal(t, http.StatusCreated, code)
+
+	var bid auction.FullBid
+	code = request(t, server, "org2/bidder2", http.MethodGet, "/auctions/1/bids/"+bid2.BidID, nil, &bid)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, 300, bid.Price)
+
+	ledger.Advance(time.Hour)
+
+	var closed auction.Auction
+	code = request(t, server, "org1/seller", http.MethodPost, "/auctions/1/close", nil, &closed)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "closed", closed.Status)
+	require.Len(t, closed.PrivateBids, 2)
+
+	code = request(t, server, "org1/bidder1", http.MethodPost, "/auctions/1/bids/"+bid1.BidID+"/reveal", nil, nil)
+	require.Equal(t, http.StatusOK, code)
+	code = request(t, server, "org2/bidder2", http.MethodPost, "/auctions/1/bids/"+bid2.BidID+"/reveal", nil, nil)
+	require.Equal(t, http.StatusOK, code)
+
+	var ended auction.Auction
+	code = request(t, server, "org1/seller", http.MethodPost, "/auctions/1/end", nil, &ended)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "ended", ended.Status)
+	require.Equal(t, 150, ended.Price)
+
+	var account rest.AccountResponse
+	code = request(t, server, "org2/bidder2", http.MethodGet, "/account", nil, &account)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, 850, account.Balance)
+
+	code = request(t, server, "org2/bidder2", http.MethodGet, "/items/painting", nil, &item)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, account.ID, item.Owner)
+
+	var result auction.AuctionQueryResult
+	code = request(t, server, "org2/bidder2", http.MethodGet, "/auctions?status=ended", nil, &result)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, result.Auctions, 1)
+}
+
+func TestRequestErrors(t *testing.T) {
+	ledger := fakeledger.New()
+	server := rest.NewServer(tokens, &submitter{ledger: ledger})
+
+	var errResp rest.ErrorResponse
+	code := request(t, server, "", http.MethodGet, "/auctions/1", nil, &errResp)
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	code = request(t, server, "org3/seller", http.MethodGet, "/auctions/1", nil, &errResp)
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	code = request(t, server, "org1/seller", http.MethodPost, "/auctions", map[string]interface{}{"id": "1", "price": 10}, &errResp)
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Contains(t, errResp.Error, "unknown field")
+
+	code = request(t, server, "org1/bidder", http.MethodPost, "/auctions/1/bids", rest.CreateBidRequest{Price: 0}, &errResp)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code = request(t, server, "org1/bidder", http.MethodPost, "/auctions/1/bids", rest.CreateBidRequest{Price: 100, MaxPrice: 50}, &errResp)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code = request(t, server, "org1/bidder", http.MethodPost, "/auctions/1/bids", rest.CreateBidRequest{Price: 100, MaxPrice: 100}, &errResp)
+	require.Equal(t, http.StatusBadGateway, code)
+	require.Contains(t, errResp.Error, "Auction 1 does not exist")
+}
+
+func TestRequestRequiresTokenOfIdentity(t *testing.T) {
+	server := rest.NewServer(tokens, &submitter{ledger: fakeledger.New()})
+
+	send := func(token string, identity string) int {
+		req := httptest.NewRequest(http.MethodGet, "/account", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if identity != "" {
+			req.Header.Set(rest.IdentityHeader, identity)
+		}
+
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// The identity header alone does not authenticate the caller.
+	require.Equal(t, http.StatusUnauthorized, send("", "org1/seller"))
+	require.Equal(t, http.StatusUnauthorized, send("guessed", "org1/seller"))
+
+	// A caller cannot ask for the identity of another user.
+	require.Equal(t, http.StatusForbidden, send("token-org2/bidder2", "org1/seller"))
+
+	// Without the header, a request runs as the identity of its token.
+	require.Equal(t, http.StatusOK, send("token-org2/bidder2", ""))
+	require.Equal(t, http.StatusOK, send("token-org2/bidder2", "org2/bidder2"))
+}
+
+func TestOpenAPI(t *testing.T) {
+	server := rest.NewServer(tokens, &submitter{ledger: fakeledger.New()})
+
+	var document struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	code := request(t, server, "org1/seller", http.MethodGet, "/openapi.json", nil, &document)
+	require.Equal(t, http.StatusOK, code)
+
+	require.Equal(t, "3.0.3", document.OpenAPI)
+	require.Contains(t, document.Paths, "/auctions/{id}/bids")
+	require.Contains(t, document.Paths["/auctions/{id}/bids"], "post")
+	require.Contains(t, document.Paths["/auctions/{id}/bids/{bidID}"], "delete")
+	require.Contains(t, document.Paths["/account/transfer"], "post")
+	require.Contains(t, document.Paths["/auctions/{id}/accept"], "post")
+	require.Contains(t, document.Paths["/auctions/{id}/open-bids"], "post")
+
+	for _, name := range []string{"Auction", "FullBid", "BidHash", "CreateAuctionRequest", "CreateBidRequest", "ErrorResponse"} {
+		require.Contains(t, document.Components.Schemas, name)
+	}
+	require.NotContains(t, document.Components.Schemas["Auction"], "$id")
+
+	// References of the chaincode schemas point to the components of the document.
+	bids := document.Components.Schemas["Auction"]["properties"].(map[string]interface{})["privateBids"]
+	encoded, err := json.Marshal(bids)
+	require.NoError(t, err)
+	require.Contains(t, string(encoded), `"#/components/schemas/BidHash"`)
+}
+
+func TestOpenAPIRequiresRouteTransactions(t *testing.T) {
+	_, err := rest.OpenAPI([]byte(`{"contracts":{"AuctionContract":{"default":true,"transactions":[{"name":"CreateAuction"}]}}}`))
+	require.Error(t, err)
+}